@@ -0,0 +1,60 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"strconv"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// cachePeeringDBResult persists result for asn under -cache-dir so a
+// future PeeringDB outage can fall back to it instead of failing the
+// peer outright. It's a no-op when -cache-dir isn't set.
+func cachePeeringDBResult(asn uint, result PeeringDbData) {
+	if *cacheDir == "" {
+		return
+	}
+
+	data, err := json.Marshal(result)
+	if err != nil {
+		log.Warnf("Marshal PeeringDB cache entry for AS%d: %v", asn, err)
+		return
+	}
+
+	if err := os.MkdirAll(*cacheDir, 0755); err != nil {
+		log.Warnf("Create cache dir %s: %v", *cacheDir, err)
+		return
+	}
+
+	if err := ioutil.WriteFile(cachePeeringDBPath(asn), data, 0644); err != nil {
+		log.Warnf("Write PeeringDB cache entry for AS%d: %v", asn, err)
+	}
+}
+
+// loadCachedPeeringDBResult reads back a previously cached PeeringDB
+// result. It errors if -cache-dir isn't set or no entry exists yet.
+func loadCachedPeeringDBResult(asn uint) (PeeringDbData, error) {
+	if *cacheDir == "" {
+		return PeeringDbData{}, fmt.Errorf("no -cache-dir configured")
+	}
+
+	data, err := ioutil.ReadFile(cachePeeringDBPath(asn))
+	if err != nil {
+		return PeeringDbData{}, err
+	}
+
+	var result PeeringDbData
+	if err := json.Unmarshal(data, &result); err != nil {
+		return PeeringDbData{}, fmt.Errorf("parse cached PeeringDB entry for AS%d: %v", asn, err)
+	}
+
+	return result, nil
+}
+
+func cachePeeringDBPath(asn uint) string {
+	return path.Join(*cacheDir, "AS"+strconv.Itoa(int(asn))+".json")
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// ReconcileResult summarizes a single generate-and-configure pass so
+// the daemon's statusz endpoint has something to render.
+type ReconcileResult struct {
+	RanAt        time.Time
+	Peers        map[string]PeerStatus
+	Errors       []string
+	Reconfigured bool
+}
+
+// PeerStatus is the per-peer slice of a ReconcileResult shown on
+// statusz.
+type PeerStatus struct {
+	Asn              uint
+	Type             string
+	QueryTime        string
+	PrefixCount4     int
+	PrefixCount6     int
+	ImportLimit4     uint
+	ImportLimit6     uint
+	PeeringDbMaxPfx4 uint
+	PeeringDbMaxPfx6 uint
+}
+
+// Daemon keeps bcg running under something like systemd: it reruns
+// generate on -refresh-interval, only triggers `birdc configure` when
+// the generated files actually changed (by content hash), and serves
+// the last run's results on /statusz.
+type Daemon struct {
+	interval time.Duration
+	generate func(d *Daemon) *ReconcileResult
+
+	mu         sync.RWMutex
+	lastResult *ReconcileResult
+	fileHashes map[string]string
+}
+
+// NewDaemon builds a Daemon that calls generate every interval.
+// generate is passed the Daemon itself so it can feed file contents
+// through hashChanged.
+func NewDaemon(interval time.Duration, generate func(d *Daemon) *ReconcileResult) *Daemon {
+	return &Daemon{
+		interval:   interval,
+		generate:   generate,
+		fileHashes: map[string]string{},
+	}
+}
+
+// Run reconciles immediately and then every interval, until ctx is
+// canceled.
+func (d *Daemon) Run(ctx context.Context) {
+	d.reconcile()
+
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			d.reconcile()
+		}
+	}
+}
+
+func (d *Daemon) reconcile() {
+	log.Info("Daemon: starting reconciliation pass")
+	result := d.generate(d)
+
+	d.mu.Lock()
+	d.lastResult = result
+	d.mu.Unlock()
+
+	log.Infof("Daemon: reconciliation pass complete (reconfigured=%v, errors=%d)", result.Reconfigured, len(result.Errors))
+}
+
+// hashChanged reports whether contents differ from the last time
+// filename was hashed, and updates the stored hash either way. A
+// filename seen for the first time is always reported as changed.
+func (d *Daemon) hashChanged(filename string, contents []byte) bool {
+	sum := fmt.Sprintf("%x", sha256.Sum256(contents))
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.fileHashes[filename] == sum {
+		return false
+	}
+	d.fileHashes[filename] = sum
+	return true
+}
+
+// StatusZHandler renders the last reconciliation result as plain text,
+// in the style of a classic /statusz debug endpoint.
+func (d *Daemon) StatusZHandler(w http.ResponseWriter, r *http.Request) {
+	d.mu.RLock()
+	result := d.lastResult
+	d.mu.RUnlock()
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+
+	if result == nil {
+		fmt.Fprintln(w, "bcg statusz: no reconciliation has run yet")
+		return
+	}
+
+	fmt.Fprintf(w, "bcg statusz - last run %s\n\n", result.RanAt.Format(time.RFC1123))
+
+	peerNames := make([]string, 0, len(result.Peers))
+	for name := range result.Peers {
+		peerNames = append(peerNames, name)
+	}
+	sort.Strings(peerNames)
+
+	for _, name := range peerNames {
+		p := result.Peers[name]
+		fmt.Fprintf(w, "%s (AS%d, %s)\n", name, p.Asn, p.Type)
+		fmt.Fprintf(w, "  last query: %s\n", p.QueryTime)
+		fmt.Fprintf(w, "  prefixes: v4=%d v6=%d\n", p.PrefixCount4, p.PrefixCount6)
+		fmt.Fprintf(w, "  import limit: v4=%d (PeeringDB max %d)  v6=%d (PeeringDB max %d)\n\n",
+			p.ImportLimit4, p.PeeringDbMaxPfx4, p.ImportLimit6, p.PeeringDbMaxPfx6)
+	}
+
+	if len(result.Errors) > 0 {
+		fmt.Fprintf(w, "errors:\n")
+		for _, e := range result.Errors {
+			fmt.Fprintf(w, "  - %s\n", e)
+		}
+		fmt.Fprintln(w)
+	}
+
+	fmt.Fprintf(w, "reconfigured BIRD: %v\n", result.Reconfigured)
+
+	protocols, err := queryBirdProtocols()
+	if err != nil {
+		fmt.Fprintf(w, "\nBIRD protocol state: unavailable (%v)\n", err)
+		return
+	}
+	fmt.Fprintf(w, "\nBIRD protocol state:\n%s\n", protocols)
+}
+
+// queryBirdProtocols asks BIRD for its protocol table over the control
+// socket. Unlike runBirdCommand, it returns an error instead of
+// Fatalf-ing, since a statusz request shouldn't be able to kill the
+// daemon.
+func queryBirdProtocols() (string, error) {
+	conn, err := net.Dial("unix", *birdSocket)
+	if err != nil {
+		return "", fmt.Errorf("BIRD socket connect: %v", err)
+	}
+	defer conn.Close()
+
+	// Discard the BIRD banner
+	if _, err := readNoBufferSafe(conn); err != nil {
+		return "", fmt.Errorf("BIRD read banner: %v", err)
+	}
+
+	if _, err := conn.Write([]byte("show protocols\n")); err != nil {
+		return "", fmt.Errorf("BIRD write: %v", err)
+	}
+
+	response, err := readNoBufferSafe(conn)
+	if err != nil {
+		return "", fmt.Errorf("BIRD read: %v", err)
+	}
+
+	return response, nil
+}
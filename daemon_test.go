@@ -0,0 +1,38 @@
+package main
+
+import "testing"
+
+func TestHashChangedFirstSeenIsAlwaysChanged(t *testing.T) {
+	d := NewDaemon(0, nil)
+
+	if !d.hashChanged("bird.conf", []byte("v1")) {
+		t.Error("a filename seen for the first time should report changed")
+	}
+}
+
+func TestHashChangedSameContentsIsUnchanged(t *testing.T) {
+	d := NewDaemon(0, nil)
+
+	d.hashChanged("bird.conf", []byte("v1"))
+	if d.hashChanged("bird.conf", []byte("v1")) {
+		t.Error("identical contents on a second call should report unchanged")
+	}
+}
+
+func TestHashChangedDifferentContentsIsChanged(t *testing.T) {
+	d := NewDaemon(0, nil)
+
+	d.hashChanged("bird.conf", []byte("v1"))
+	if !d.hashChanged("bird.conf", []byte("v2")) {
+		t.Error("different contents should report changed")
+	}
+}
+
+func TestHashChangedTracksFilenamesIndependently(t *testing.T) {
+	d := NewDaemon(0, nil)
+
+	d.hashChanged("AS64500.conf", []byte("same"))
+	if !d.hashChanged("AS64501.conf", []byte("same")) {
+		t.Error("a different filename with identical contents should still report changed the first time")
+	}
+}
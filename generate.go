@@ -0,0 +1,228 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+	"unicode"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// reconcileMu serializes reconcile() so the daemon's own ticker and a
+// gRPC-triggered TriggerReconcile can't run concurrently and race on
+// writing the same BIRD config files or invoking `birdc configure` at
+// the same time.
+var reconcileMu sync.Mutex
+
+// reconcileFunc adapts a plain function to api.Reconciler so the
+// -grpc-listen TriggerReconcile RPC can drive the same reconcile pass
+// as the CLI and daemon paths.
+type reconcileFunc func() (bool, []string)
+
+func (f reconcileFunc) Reconcile() (bool, []string) { return f() }
+
+// reconcile runs the enrichment pipeline against config, writes the
+// global/peer/UI BIRD files (unless -dryrun), and reloads BIRD only if
+// something daemon has already seen actually changed. It backs both
+// the one-shot CLI path and the daemon's periodic ticks.
+//
+// daemon may be nil, in which case BIRD is reloaded unconditionally
+// (unless -dryrun), even if writing one of the files failed.
+func reconcile(config Config, peerTemplate, globalTemplate, uiTemplate *template.Template, daemon *Daemon) *ReconcileResult {
+	reconcileMu.Lock()
+	defer reconcileMu.Unlock()
+
+	result := &ReconcileResult{RanAt: time.Now(), Peers: map[string]PeerStatus{}}
+
+	state := &State{Config: config}
+	orchestrator := NewOrchestrator(
+		&PeeringDBProcessor{},
+		&IRRProcessor{},
+		&RPKIProcessor{},
+		&SecretGenProcessor{},
+	)
+	if err := orchestrator.Run(context.Background(), state); err != nil {
+		log.Warnf("Pipeline finished with errors: %v", err)
+	}
+	for _, e := range state.Errors {
+		result.Errors = append(result.Errors, e.Error())
+	}
+	config = state.Config
+
+	// In one-shot mode there's no hash history to compare against, so
+	// always treat the run as changed and reload BIRD like bcg always has.
+	changed := daemon == nil
+
+	if !*dryRun {
+		if writeGlobalConfig(config, globalTemplate, daemon) {
+			changed = true
+		}
+
+		files, err := filepath.Glob(path.Join(*outputDirectory, "AS*.conf"))
+		if err != nil {
+			log.Errorf("Glob old peer configs: %v", err)
+			result.Errors = append(result.Errors, err.Error())
+		}
+		for _, f := range files {
+			if err := os.Remove(f); err != nil {
+				log.Errorf("Removing old config file %s: %v", f, err)
+				result.Errors = append(result.Errors, err.Error())
+			}
+		}
+	} else {
+		log.Info("Dry run is enabled, skipped writing global config and removing old peer configs")
+	}
+
+	for peerName, peerData := range config.Peers {
+		_peerName := strings.ReplaceAll(normalize(peerName), "-", "_")
+		if unicode.IsDigit(rune(_peerName[0])) {
+			_peerName = "PEER_" + _peerName
+		}
+		peerData.Name = _peerName
+
+		// Default query time for peer types the pipeline doesn't query
+		// (upstream, import-valid), or when a pipeline error left it unset.
+		if peerData.QueryTime == "" {
+			peerData.QueryTime = "[No operations performed]"
+		}
+
+		log.Infof("Checking config for %s AS%d", peerName, peerData.Asn)
+
+		if !(peerData.Type == "upstream" || peerData.Type == "peer" || peerData.Type == "downstream" || peerData.Type == "import-valid") {
+			log.Errorf("Peer %s has an invalid type attribute. Must be upstream, peer, downstream, or import-valid", peerName)
+			result.Errors = append(result.Errors, fmt.Sprintf("peer %s: invalid type %q", peerName, peerData.Type))
+			continue
+		}
+
+		if peerData.LocalPref == 0 {
+			peerData.LocalPref = 100
+		}
+
+		if peerData.Type == "upstream" || peerData.Type == "import-valid" {
+			if peerData.ImportLimit4 == 0 {
+				peerData.ImportLimit4 = 1000000 // 1M routes
+				log.Infof("Upstream/Import-Valid %s has no IPv4 import limit configured. Setting to 1,000,000", peerName)
+			} else if peerData.ImportLimit4 <= 900000 {
+				log.Infof("Upstream/Import-Valid %s has a low IPv4 import limit configured. You may want to increase the import limit.", peerName)
+			}
+
+			if peerData.ImportLimit6 == 0 {
+				peerData.ImportLimit6 = 150000 // 150k routes
+				log.Infof("Upstream/Import-Valid %s has no IPv6 import limit configured. Setting to 150,000", peerName)
+			} else if peerData.ImportLimit6 <= 98000 {
+				log.Infof("Upstream/Import-Valid %s has a low IPv6 import limit configured. You may want to increase the import limit.", peerName)
+			}
+		}
+
+		result.Peers[peerName] = PeerStatus{
+			Asn:              peerData.Asn,
+			Type:             peerData.Type,
+			QueryTime:        peerData.QueryTime,
+			PrefixCount4:     len(peerData.PrefixSet4),
+			PrefixCount6:     len(peerData.PrefixSet6),
+			ImportLimit4:     peerData.ImportLimit4,
+			ImportLimit6:     peerData.ImportLimit6,
+			PeeringDbMaxPfx4: peerData.PeeringDbMaxPfx4,
+			PeeringDbMaxPfx6: peerData.PeeringDbMaxPfx6,
+		}
+
+		if !*dryRun {
+			if writePeerConfig(peerName, peerData, config, peerTemplate, daemon) {
+				changed = true
+			}
+		} else {
+			log.Infof("Dry run is enabled, skipped writing peer config(s)")
+		}
+	}
+
+	if !*dryRun && !*noui {
+		writeUIFile(config, uiTemplate)
+	}
+
+	if !*dryRun {
+		if changed {
+			runBirdCommand("configure")
+			result.Reconfigured = true
+		} else {
+			log.Debug("Generated files unchanged, skipping birdc configure")
+		}
+	}
+
+	return result
+}
+
+// writeGlobalConfig renders global.tmpl and writes it to bird.conf,
+// returning whether its contents changed since the last write (always
+// true when daemon is nil). It returns false if rendering or writing
+// the file fails.
+func writeGlobalConfig(config Config, globalTemplate *template.Template, daemon *Daemon) bool {
+	var buf bytes.Buffer
+	if err := globalTemplate.ExecuteTemplate(&buf, "global.tmpl", config); err != nil {
+		log.Errorf("Execute global template: %v", err)
+		return false
+	}
+
+	filename := path.Join(*outputDirectory, "bird.conf")
+	changed := daemon == nil || daemon.hashChanged(filename, buf.Bytes())
+
+	// 0600, not 0644: global.tmpl has access to config.Peers and may
+	// render BGP MD5 passwords (including generated ones, via
+	// Peer.Password), so this file can hold secrets just like the
+	// per-peer config below.
+	if err := ioutil.WriteFile(filename, buf.Bytes(), 0600); err != nil {
+		log.Errorf("Write global BIRD output file: %v", err)
+		return false
+	}
+
+	return changed
+}
+
+// writePeerConfig renders peer.tmpl for a single peer and writes its
+// AS<n>_<name>.conf file, returning whether its contents changed since
+// the last write (always true when daemon is nil). It returns false if
+// rendering or writing the file fails.
+func writePeerConfig(peerName string, peerData *Peer, config Config, peerTemplate *template.Template, daemon *Daemon) bool {
+	var buf bytes.Buffer
+	if err := peerTemplate.ExecuteTemplate(&buf, "peer.tmpl", &PeerTemplate{*peerData, config}); err != nil {
+		log.Errorf("Execute peer template for %s: %v", peerName, err)
+		return false
+	}
+
+	filename := path.Join(*outputDirectory, "AS"+strconv.Itoa(int(peerData.Asn))+"_"+normalize(peerName)+".conf")
+	changed := daemon == nil || daemon.hashChanged(filename, buf.Bytes())
+
+	// 0600: peer.tmpl renders the BGP MD5 password (via Peer.Password)
+	// directly into this file, so it needs the same protection as the
+	// -secret-dir sidecar it was read from.
+	if err := ioutil.WriteFile(filename, buf.Bytes(), 0600); err != nil {
+		log.Errorf("Write peer config for %s: %v", peerName, err)
+		return false
+	}
+
+	log.Infof("Wrote peer specific config for AS%d", peerData.Asn)
+	return changed
+}
+
+// writeUIFile renders ui.tmpl to the -uifile path.
+func writeUIFile(config Config, uiTemplate *template.Template) {
+	uiFileObj, err := os.Create(*uiFile)
+	if err != nil {
+		log.Errorf("Create UI output file: %v", err)
+		return
+	}
+	defer uiFileObj.Close()
+
+	if err := uiTemplate.ExecuteTemplate(uiFileObj, "ui.tmpl", config); err != nil {
+		log.Errorf("Execute ui template: %v", err)
+	}
+}
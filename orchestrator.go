@@ -0,0 +1,42 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Orchestrator runs a fixed pipeline of Processors against a shared
+// State, one after another. Unlike the old inline main loop, a
+// processor failure (e.g. one peer's PeeringDB lookup timing out)
+// doesn't Fatalf the whole run: it's logged, recorded on the State,
+// and the remaining processors still run.
+type Orchestrator struct {
+	processors []Processor
+}
+
+// NewOrchestrator builds an Orchestrator that runs the given
+// processors in order.
+func NewOrchestrator(processors ...Processor) *Orchestrator {
+	return &Orchestrator{processors: processors}
+}
+
+// Run executes every processor in order against state. It returns an
+// error summarizing how many processors failed, but always runs the
+// full pipeline first.
+func (o *Orchestrator) Run(ctx context.Context, state *State) error {
+	for _, p := range o.processors {
+		log.Debugf("Running processor %s", p.Name())
+		if err := p.Run(ctx, state); err != nil {
+			log.Errorf("Processor %s: %v", p.Name(), err)
+			state.AddError(fmt.Errorf("%s: %w", p.Name(), err))
+		}
+	}
+
+	if len(state.Errors) > 0 {
+		return fmt.Errorf("%d processor(s) reported errors", len(state.Errors))
+	}
+
+	return nil
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"testing"
+)
+
+// fakeProcessor is a Processor whose Run behavior is supplied by a
+// test, letting Orchestrator be exercised without any real enrichment
+// dependencies.
+type fakeProcessor struct {
+	name string
+	err  error
+	ran  *bool
+}
+
+func (p *fakeProcessor) Name() string { return p.name }
+
+func (p *fakeProcessor) Run(ctx context.Context, state *State) error {
+	if p.ran != nil {
+		*p.ran = true
+	}
+	return p.err
+}
+
+func TestOrchestratorRunsEveryProcessor(t *testing.T) {
+	var firstRan, secondRan bool
+	o := NewOrchestrator(
+		&fakeProcessor{name: "first", ran: &firstRan},
+		&fakeProcessor{name: "second", ran: &secondRan},
+	)
+
+	state := &State{Config: Config{}}
+	if err := o.Run(context.Background(), state); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if !firstRan || !secondRan {
+		t.Error("expected both processors to run")
+	}
+	if len(state.Errors) != 0 {
+		t.Errorf("state.Errors = %v, want none", state.Errors)
+	}
+}
+
+func TestOrchestratorRunsRemainingProcessorsAfterAnError(t *testing.T) {
+	var secondRan, thirdRan bool
+	o := NewOrchestrator(
+		&fakeProcessor{name: "first", err: fmt.Errorf("boom")},
+		&fakeProcessor{name: "second", ran: &secondRan},
+		&fakeProcessor{name: "third", ran: &thirdRan},
+	)
+
+	state := &State{Config: Config{}}
+	err := o.Run(context.Background(), state)
+	if err == nil {
+		t.Fatal("expected Run to return an error summarizing the failure")
+	}
+	if !secondRan || !thirdRan {
+		t.Error("a failing processor must not stop the rest of the pipeline")
+	}
+	if len(state.Errors) != 1 {
+		t.Fatalf("state.Errors = %v, want exactly 1", state.Errors)
+	}
+}
+
+func TestOrchestratorAggregatesMultipleErrors(t *testing.T) {
+	o := NewOrchestrator(
+		&fakeProcessor{name: "first", err: fmt.Errorf("one")},
+		&fakeProcessor{name: "second", err: fmt.Errorf("two")},
+	)
+
+	state := &State{Config: Config{}}
+	if err := o.Run(context.Background(), state); err == nil {
+		t.Fatal("expected an error")
+	}
+	if len(state.Errors) != 2 {
+		t.Errorf("state.Errors = %v, want 2", state.Errors)
+	}
+}
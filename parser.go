@@ -1,10 +1,13 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"github.com/kennygrant/sanitize"
+	"github.com/natesales/bcg/pkg/api"
 	"github.com/pelletier/go-toml"
 	log "github.com/sirupsen/logrus"
 	"gopkg.in/yaml.v2"
@@ -13,45 +16,71 @@ import (
 	"net"
 	"net/http"
 	"os"
-	"os/exec"
 	"path"
-	"path/filepath"
-	"strconv"
 	"strings"
 	"text/template"
 	"time"
-	"unicode"
 )
 
 var release = "devel" // This is set by go build
 
 // Peer contains all information specific to a single peer network
 type Peer struct {
-	Asn           uint     `yaml:"asn" toml:"ASN" json:"asn"`
-	Type          string   `yaml:"type" toml:"Type" json:"type"`
-	Prepends      uint     `yaml:"prepends" toml:"Prepends" json:"prepends"`
-	LocalPref     uint     `yaml:"local-pref" toml:"LocalPref" json:"local-pref"`
-	Multihop      bool     `yaml:"multihop" toml:"Multihop" json:"multihop"`
-	Passive       bool     `yaml:"passive" toml:"Passive" json:"passive"`
-	Disabled      bool     `yaml:"disabled" toml:"Disabled" json:"disabled"`
-	Password      string   `yaml:"password" toml:"Password" json:"password"`
-	Port          uint16   `yaml:"port" toml:"Port" json:"port"`
-	PreImport     string   `yaml:"pre-import" toml:"PreImport" json:"pre-import"`
-	PreExport     string   `yaml:"pre-export" toml:"PreExport" json:"pre-export"`
-	NeighborIps   []string `yaml:"neighbors" toml:"Neighbors" json:"neighbors"`
-	ImportLimit4  uint     `yaml:"import-limit4" toml:"ImportLimit4" json:"import-limit4"`
-	ImportLimit6  uint     `yaml:"import-limit6" toml:"ImportLimit6" json:"import-limit6"`
-	SkipFilter    bool     `yaml:"skip-filter" toml:"SkipFilter" json:"skip-filter"`
-	RsClient      bool     `yaml:"rs-client" toml:"RSClient" json:"rs-client"`
-	RrClient      bool     `yaml:"rr-client" toml:"RRClient" json:"rr-client"`
-	Bfd           bool     `yaml:"bfd" toml:"BFD" json:"bfd"`
-	SessionGlobal string   `yaml:"session-global" toml:"SessionGlobal" json:"SessionGlobal"`
-
-	AsSet      string   `yaml:"-" toml:"-" json:"-"`
-	QueryTime  string   `yaml:"-" toml:"-" json:"-"`
-	Name       string   `yaml:"-" toml:"-" json:"-"`
-	PrefixSet4 []string `yaml:"-" toml:"-" json:"-"`
-	PrefixSet6 []string `yaml:"-" toml:"-" json:"-"`
+	Asn            uint     `yaml:"asn" toml:"ASN" json:"asn"`
+	Type           string   `yaml:"type" toml:"Type" json:"type"`
+	Prepends       uint     `yaml:"prepends" toml:"Prepends" json:"prepends"`
+	LocalPref      uint     `yaml:"local-pref" toml:"LocalPref" json:"local-pref"`
+	Multihop       bool     `yaml:"multihop" toml:"Multihop" json:"multihop"`
+	Passive        bool     `yaml:"passive" toml:"Passive" json:"passive"`
+	Disabled       bool     `yaml:"disabled" toml:"Disabled" json:"disabled"`
+	PasswordConfig string   `yaml:"password" toml:"Password" json:"password"`
+	Port           uint16   `yaml:"port" toml:"Port" json:"port"`
+	PreImport      string   `yaml:"pre-import" toml:"PreImport" json:"pre-import"`
+	PreExport      string   `yaml:"pre-export" toml:"PreExport" json:"pre-export"`
+	NeighborIps    []string `yaml:"neighbors" toml:"Neighbors" json:"neighbors"`
+	ImportLimit4   uint     `yaml:"import-limit4" toml:"ImportLimit4" json:"import-limit4"`
+	ImportLimit6   uint     `yaml:"import-limit6" toml:"ImportLimit6" json:"import-limit6"`
+	SkipFilter     bool     `yaml:"skip-filter" toml:"SkipFilter" json:"skip-filter"`
+	RsClient       bool     `yaml:"rs-client" toml:"RSClient" json:"rs-client"`
+	RrClient       bool     `yaml:"rr-client" toml:"RRClient" json:"rr-client"`
+	Bfd              bool     `yaml:"bfd" toml:"BFD" json:"bfd"`
+	SessionGlobal    string   `yaml:"session-global" toml:"SessionGlobal" json:"SessionGlobal"`
+	PasswordGenerate bool     `yaml:"password-generate" toml:"PasswordGenerate" json:"password-generate"`
+
+	AsSet            string   `yaml:"-" toml:"-" json:"-"`
+	QueryTime        string   `yaml:"-" toml:"-" json:"-"`
+	Name             string   `yaml:"-" toml:"-" json:"-"`
+	PrefixSet4       []string `yaml:"-" toml:"-" json:"-"`
+	PrefixSet6       []string `yaml:"-" toml:"-" json:"-"`
+	PeeringDbMaxPfx4 uint     `yaml:"-" toml:"-" json:"-"`
+	PeeringDbMaxPfx6 uint     `yaml:"-" toml:"-" json:"-"`
+	RpkiValid        []string `yaml:"-" toml:"-" json:"-"`
+	RpkiInvalid      []string `yaml:"-" toml:"-" json:"-"`
+	RpkiUnknown      []string `yaml:"-" toml:"-" json:"-"`
+	PasswordFile     string   `yaml:"-" toml:"-" json:"-"`
+}
+
+// Password returns the peer's BGP session password, so templates can
+// call {{ .Peer.Password }} without needing to know whether it came
+// straight from config.yml or was generated by SecretGenProcessor.
+// Peers with password: "auto" or password-generate: true have
+// PasswordFile set and read their password from that sidecar (or get
+// an error if no sidecar was generated for them); every other peer
+// gets its literal PasswordConfig value.
+func (p *Peer) Password() (string, error) {
+	if p.PasswordFile == "" {
+		if p.PasswordConfig == "auto" || p.PasswordGenerate {
+			return "", fmt.Errorf("no generated password for AS%d", p.Asn)
+		}
+		return p.PasswordConfig, nil
+	}
+
+	data, err := ioutil.ReadFile(p.PasswordFile)
+	if err != nil {
+		return "", fmt.Errorf("read secret file for AS%d: %v", p.Asn, err)
+	}
+
+	return string(data), nil
 }
 
 // Config contains global configuration about this router and BCG instance
@@ -101,85 +130,43 @@ var (
 	debug              = flag.Bool("debug", false, "Show debugging messages")
 	uiFile             = flag.String("uifile", "/tmp/bcg-ui.html", "File to store web UI index page")
 	noui               = flag.Bool("noui", false, "Disable generating web UI")
+	daemonMode         = flag.Bool("daemon", false, "Run continuously, reconciling every -refresh-interval instead of exiting")
+	refreshInterval    = flag.Duration("refresh-interval", time.Hour, "How often to re-query PeeringDB/IRR and reconcile in -daemon mode")
+	statuszListen      = flag.String("statusz-listen", "127.0.0.1:8080", "Address to serve the /statusz debug endpoint on in -daemon mode")
+	rpkiSource         = flag.String("rpki-source", "", "RPKI VRP source to validate prefixes against: rtr://host:port or a path to a rpki-client/routinator JSON VRP dump")
+	concurrency        = flag.Int("concurrency", 8, "Maximum number of peers to enrich (PeeringDB/IRR) concurrently")
+	peerTimeout        = flag.Duration("peer-timeout", 15*time.Second, "Per-peer timeout for PeeringDB/IRR enrichment")
+	cacheDir           = flag.String("cache-dir", "", "Directory to cache per-peer PeeringDB results for fallback during an outage (disabled if empty)")
+	grpcListen         = flag.String("grpc-listen", "", "Address to serve the pkg/api peering gRPC service on, letting an external controller manage peers dynamically (disabled if empty)")
+	grpcDB             = flag.String("grpc-db", "/var/lib/bcg/api.db", "BoltDB file backing the -grpc-listen peer store")
+	secretKey          = flag.String("secret-key", "", "File containing the HMAC key used to derive generated peer passwords (required for password: \"auto\" or password-generate: true peers)")
+	secretDir          = flag.String("secret-dir", "/etc/bird/secrets", "Directory to write generated peer password sidecar files to")
+	rotateSecrets      = flag.Bool("rotate-secrets", false, "Bump the generation counter and re-derive generated peer passwords")
 )
 
-// Query PeeringDB for an ASN
-func getPeeringDbData(asn uint) PeeringDbData {
-	httpClient := http.Client{Timeout: time.Second * 5}
-	req, err := http.NewRequest(http.MethodGet, "https://peeringdb.com/api/net?asn="+strconv.Itoa(int(asn)), nil)
-	if err != nil {
-		log.Fatalf("PeeringDB GET (This peer might not have a PeeringDB page): %v", err)
-	}
-
-	res, err := httpClient.Do(req)
-	if err != nil {
-		log.Fatalf("PeeringDB GET Request: %v", err)
-	}
-
-	if res.Body != nil {
-		//noinspection GoUnhandledErrorResult
-		defer res.Body.Close()
-	}
-
-	body, err := ioutil.ReadAll(res.Body)
-	if err != nil {
-		log.Fatalf("PeeringDB Read: %v", err)
-	}
-
-	var peeringDbResponse PeeringDbResponse
-	if err := json.Unmarshal(body, &peeringDbResponse); err != nil {
-		log.Fatalf("PeeringDB JSON Unmarshal: %v", err)
-	}
-
-	if len(peeringDbResponse.Data) < 1 {
-		log.Fatalf("Peer %d doesn't have a valid PeeringDB entry. Try import-valid or ask the network to update their account.", asn)
-	}
-
-	return peeringDbResponse.Data[0]
-}
+// Nonbuffered io Reader
+func readNoBuffer(reader io.Reader) string {
+	buf := make([]byte, 1024)
+	n, err := reader.Read(buf[:])
 
-// Use bgpq4 to generate a prefix filter and return only the filter lines
-func getPrefixFilter(asSet string, family uint8, irrdb string) []string {
-	// Run bgpq4 for BIRD format with aggregation enabled
-	log.Infof("Running bgpq4 -h %s -Ab%d %s", irrdb, family, asSet)
-	cmd := exec.Command("bgpq4", "-h", irrdb, "-Ab"+strconv.Itoa(int(family)), asSet)
-	stdout, err := cmd.Output()
 	if err != nil {
-		log.Fatalf("bgpq4 error: %v", err.Error())
-	}
-
-	// Remove whitespace and commas from output
-	output := strings.ReplaceAll(string(stdout), ",\n    ", "\n")
-
-	// Remove array prefix
-	output = strings.ReplaceAll(output, "NN = [\n    ", "")
-
-	// Remove array suffix
-	output = strings.ReplaceAll(output, "];", "")
-
-	// Check for empty IRR
-	if output == "" {
-		log.Warnf("Peer with as-set %s has no IPv%d prefixes. Disabled IPv%d connectivity.", asSet, family, family)
-		return []string{}
+		log.Fatalf("BIRD read error: ", err)
 	}
 
-	// Remove whitespace (in this case there should only be trailing whitespace)
-	output = strings.TrimSpace(output)
-
-	// Split output by newline
-	return strings.Split(output, "\n")
+	return string(buf[:n])
 }
 
-// Nonbuffered io Reader
-func readNoBuffer(reader io.Reader) string {
+// readNoBufferSafe is readNoBuffer without the log.Fatalf: it's used by
+// callers like the daemon's statusz handler that can't afford to bring
+// down the whole process over a transient BIRD socket hiccup.
+func readNoBufferSafe(reader io.Reader) (string, error) {
 	buf := make([]byte, 1024)
 	n, err := reader.Read(buf[:])
-
 	if err != nil {
-		log.Fatalf("BIRD read error: ", err)
+		return "", err
 	}
 
-	return string(buf[:n])
+	return string(buf[:n]), nil
 }
 
 // Run a bird command
@@ -222,11 +209,14 @@ func normalize(input string) string {
 	return input
 }
 
-// Load a configuration file (YAML, JSON, or TOML)
-func loadConfig() Config {
+// Load a configuration file (YAML, JSON, or TOML). Returns an error
+// instead of exiting so that callers which can run without a config
+// file (e.g. -grpc-listen with no config.yml at all) can recover from
+// a missing file instead of crashing the whole process.
+func loadConfig() (Config, error) {
 	configFile, err := ioutil.ReadFile(*configFilename)
 	if err != nil {
-		log.Fatalf("Reading %s: %v", *configFilename, err)
+		return Config{}, fmt.Errorf("reading %s: %w", *configFilename, err)
 	}
 
 	var config Config
@@ -235,29 +225,159 @@ func loadConfig() Config {
 	switch extension := _splitFilename[len(_splitFilename)-1]; extension {
 	case "yaml", "yml":
 		log.Info("Using YAML configuration format")
-		err := yaml.Unmarshal(configFile, &config)
-		if err != nil {
-			log.Fatalf("YAML Unmarshal: %v", err)
+		if err := yaml.Unmarshal(configFile, &config); err != nil {
+			return Config{}, fmt.Errorf("YAML Unmarshal: %v", err)
 		}
 	case "toml":
 		log.Info("Using TOML configuration format")
-		err := toml.Unmarshal(configFile, &config)
-		if err != nil {
-			log.Fatalf("TOML Unmarshal: %v", err)
+		if err := toml.Unmarshal(configFile, &config); err != nil {
+			return Config{}, fmt.Errorf("TOML Unmarshal: %v", err)
 		}
 	case "json":
 		log.Info("Using JSON configuration format")
-		err := json.Unmarshal(configFile, &config)
-		if err != nil {
-			log.Fatalf("JSON Unmarshal: %v", err)
+		if err := json.Unmarshal(configFile, &config); err != nil {
+			return Config{}, fmt.Errorf("JSON Unmarshal: %v", err)
 		}
 	default:
-		log.Fatalf("Files with extension '%s' are not supported. (Acceptable values are yaml, toml, json", extension)
+		return Config{}, fmt.Errorf("files with extension '%s' are not supported (acceptable values are yaml, toml, json)", extension)
+	}
+
+	return config, nil
+}
+
+// applyConfigDefaults fills in config's global defaults (IRRDB server,
+// RTR server, hostname, origin sets) without validating anything that
+// requires a real YAML-loaded router ID or prefixes. It's split out of
+// prepareConfig so the -grpc-listen no-config-file case can still get
+// sane defaults instead of an empty IrrDb/RtrServer that would send
+// every IRR/RPKI lookup to "".
+func applyConfigDefaults(config Config) Config {
+	// Set default IRRDB
+	if config.IrrDb == "" {
+		config.IrrDb = "rr.ntt.net"
+	}
+	log.Infof("Using IRRDB server %s", config.IrrDb)
+
+	// Set default RTR server
+	if config.RtrServer == "" {
+		config.RtrServer = "127.0.0.1"
+	}
+	log.Infof("Using RTR server %s", config.RtrServer)
+
+	hostname, err := os.Hostname()
+	if err != nil {
+		log.Warn("Unable to get hostname")
+	}
+	config.Hostname = hostname
+
+	if len(config.Prefixes) == 0 {
+		log.Info("There are no origin prefixes defined")
+	} else {
+		// Assemble originIpv{4,6} lists by address family
+		var originIpv4, originIpv6 []string
+		for _, prefix := range config.Prefixes {
+			if strings.Contains(prefix, ":") {
+				originIpv6 = append(originIpv6, prefix)
+			} else {
+				originIpv4 = append(originIpv4, prefix)
+			}
+		}
+
+		config.OriginSet4 = originIpv4
+		config.OriginSet6 = originIpv6
 	}
 
 	return config
 }
 
+// prepareConfig fills in global defaults and validates the parts of
+// config that don't require network access (router ID, origin
+// prefixes), returning an error instead of exiting so it can be
+// reused on every daemon reconciliation, not just at startup.
+func prepareConfig(config Config) (Config, error) {
+	config = applyConfigDefaults(config)
+
+	// Validate Router ID in dotted quad format
+	if net.ParseIP(config.RouterId).To4() == nil {
+		return config, fmt.Errorf("router ID %s is not in valid dotted quad notation", config.RouterId)
+	}
+
+	// Validate CIDR notation of originated prefixes
+	for _, addr := range config.Prefixes {
+		if _, _, err := net.ParseCIDR(addr); err != nil {
+			return config, fmt.Errorf("%s is not a valid IPv4 or IPv6 prefix in CIDR notation", addr)
+		}
+	}
+
+	return config, nil
+}
+
+// loadAndPrepareConfig loads and prepares config.yml, tolerating a
+// missing file when -grpc-listen is set (bcg can then run in a fully
+// gRPC-managed mode with no YAML peers at all). It's shared by the
+// initial load, the -daemon reload ticker, and TriggerReconcile so all
+// three agree on what "no config file" means.
+func loadAndPrepareConfig() (Config, error) {
+	rawConfig, err := loadConfig()
+	switch {
+	case err == nil:
+		return prepareConfig(rawConfig)
+	case *grpcListen != "" && errors.Is(err, os.ErrNotExist):
+		log.Warnf("No config file at %s, continuing with no YAML peers (gRPC-managed mode): %v", *configFilename, err)
+		return applyConfigDefaults(Config{}), nil
+	default:
+		return Config{}, err
+	}
+}
+
+// mergeStorePeers overlays the peers pushed through the -grpc-listen API
+// onto config's YAML-loaded peer set, letting an external controller
+// manage peers dynamically alongside (or instead of) config.yml. A
+// store-managed peer takes precedence over a YAML peer of the same
+// name. config.Peers itself is left untouched; a new map is returned.
+func mergeStorePeers(config Config, store api.Store) (Config, error) {
+	records, err := store.List()
+	if err != nil {
+		return config, fmt.Errorf("list gRPC-managed peers: %v", err)
+	}
+
+	merged := make(map[string]*Peer, len(config.Peers)+len(records))
+	for name, peer := range config.Peers {
+		merged[name] = peer
+	}
+	for name, record := range records {
+		merged[name] = peerFromRecord(record)
+	}
+	config.Peers = merged
+
+	return config, nil
+}
+
+// peerFromRecord converts a gRPC-managed api.PeerRecord into the Peer
+// type the rest of bcg works with.
+func peerFromRecord(record api.PeerRecord) *Peer {
+	return &Peer{
+		Asn:            uint(record.Asn),
+		Type:           record.Type,
+		Prepends:       uint(record.Prepends),
+		LocalPref:      uint(record.LocalPref),
+		Multihop:       record.Multihop,
+		Passive:        record.Passive,
+		Disabled:       record.Disabled,
+		PasswordConfig: record.Password,
+		Port:           uint16(record.Port),
+		PreImport:      record.PreImport,
+		PreExport:      record.PreExport,
+		NeighborIps:    record.Neighbors,
+		ImportLimit4:   uint(record.ImportLimit4),
+		ImportLimit6:   uint(record.ImportLimit6),
+		SkipFilter:     record.SkipFilter,
+		RsClient:       record.RsClient,
+		RrClient:       record.RrClient,
+		Bfd:            record.Bfd,
+	}
+}
+
 func main() {
 	// Enable debug logging in development releases
 	if //noinspection GoBoolExpressions
@@ -347,245 +467,132 @@ func main() {
 
 	log.Debug("Finished loading templates")
 
-	// Load the config file from configFilename flag
+	// Load the config file from configFilename flag. With -grpc-listen
+	// set, a missing config file isn't fatal: bcg can run in a fully
+	// gRPC-managed mode with no YAML peers at all.
 	log.Debugf("Loading config from %s", *configFilename)
-	config := loadConfig()
-	log.Debug("Finished loading config")
-
-	log.Debug("Linting global configuration")
-
-	// Set default IRRDB
-	if config.IrrDb == "" {
-		config.IrrDb = "rr.ntt.net"
-	}
-	log.Infof("Using IRRDB server %s", config.IrrDb)
-
-	// Set default RTR server
-	if config.RtrServer == "" {
-		config.RtrServer = "127.0.0.1"
-	}
-	log.Infof("Using RTR server %s", config.RtrServer)
-
-	// Validate Router ID in dotted quad format
-	if net.ParseIP(config.RouterId).To4() == nil {
-		log.Fatalf("Router ID %s is not in valid dotted quad notation", config.RouterId)
-	}
-
-	// Validate CIDR notation of originated prefixes
-	for _, addr := range config.Prefixes {
-		if _, _, err := net.ParseCIDR(addr); err != nil {
-			log.Fatalf("%s is not a valid IPv4 or IPv6 prefix in CIDR notation", addr)
-		}
-	}
-
-	log.Debug("Finished linting global config")
-
-	config.Hostname, err = os.Hostname()
+	config, err := loadAndPrepareConfig()
 	if err != nil {
-		log.Warn("Unable to get hostname")
-	}
-
-	if len(config.Prefixes) == 0 {
-		log.Info("There are no origin prefixes defined")
-	} else {
-		log.Debug("Building origin sets")
-
-		// Assemble originIpv{4,6} lists by address family
-		var originIpv4, originIpv6 []string
-		for _, prefix := range config.Prefixes {
-			if strings.Contains(prefix, ":") {
-				originIpv6 = append(originIpv6, prefix)
-			} else {
-				originIpv4 = append(originIpv4, prefix)
-			}
-		}
-
-		log.Debug("Finished building origin sets")
-
-		log.Debug("OriginIpv4: ", originIpv4)
-		log.Debug("OriginIpv6: ", originIpv6)
-
-		config.OriginSet4 = originIpv4
-		config.OriginSet6 = originIpv6
+		log.Fatalf("%v", err)
 	}
+	log.Debug("Finished loading config")
 
-	if !*dryRun {
-		// Create the global output file
-		log.Debug("Creating global config")
-		globalFile, err := os.Create(path.Join(*outputDirectory, "bird.conf"))
+	// apiServer and store are non-nil only when -grpc-listen is set.
+	// Every reconcile() call site below merges store's gRPC-managed
+	// peers into config (a no-op when nil) and publishes its result to
+	// apiServer (also a no-op when nil), so WatchPeerStatus subscribers
+	// and reconcile() itself see gRPC-pushed peers on every pass, not
+	// just ones triggered over gRPC.
+	var apiServer *api.Server
+	var store api.Store
+
+	if *grpcListen != "" {
+		boltStore, err := api.NewBoltStore(*grpcDB)
 		if err != nil {
-			log.Fatalf("Create global BIRD output file: %v", err)
+			log.Fatalf("Open gRPC peer store: %v", err)
 		}
-		log.Debug("Finished creating global config file")
+		defer boltStore.Close()
+		store = boltStore
+
+		apiServer = api.NewServer(store, reconcileFunc(func() (bool, []string) {
+			// Reload config.yml on every trigger, the same as the
+			// -daemon ticker, so a gRPC-triggered reconcile never
+			// reconciles against a stale snapshot of the YAML peers
+			// taken at startup.
+			cfg, err := loadAndPrepareConfig()
+			if err != nil {
+				return false, []string{err.Error()}
+			}
+			cfg, err = mergeStorePeers(cfg, store)
+			if err != nil {
+				return false, []string{err.Error()}
+			}
+			result := reconcile(cfg, peerTemplate, globalTemplate, uiTemplate, nil)
+			publishPeerStatus(apiServer, result)
+			return result.Reconfigured, result.Errors
+		}))
 
-		// Render the global template and write to disk
-		log.Debug("Writing global config file")
-		err = globalTemplate.ExecuteTemplate(globalFile, "global.tmpl", config)
+		lis, err := net.Listen("tcp", *grpcListen)
 		if err != nil {
-			log.Fatalf("Execute global template: %v", err)
+			log.Fatalf("Listen on %s: %v", *grpcListen, err)
 		}
-		log.Debug("Finished writing global config file")
 
-		// Remove old peer-specific configs
-		files, err := filepath.Glob(path.Join(*outputDirectory, "AS*.conf"))
-		if err != nil {
-			panic(err)
-		}
-		for _, f := range files {
-			if err := os.Remove(f); err != nil {
-				log.Fatalf("Removing old config files: %v", err)
+		go func() {
+			log.Infof("Serving peering gRPC API on %s", *grpcListen)
+			if err := api.NewGRPCServer(apiServer).Serve(lis); err != nil {
+				log.Errorf("gRPC server: %v", err)
 			}
-		}
-	} else {
-		log.Info("Dry run is enabled, skipped writing global config and removing old peer configs")
+		}()
 	}
 
-	// Iterate over peers
-	for peerName, peerData := range config.Peers {
-		// Set peerName
-		_peerName := strings.ReplaceAll(normalize(peerName), "-", "_")
-		if unicode.IsDigit(rune(_peerName[0])) {
-			_peerName = "PEER_" + _peerName
-		}
-
-		peerData.Name = _peerName
-
-		// Set default query time
-		peerData.QueryTime = "[No operations performed]"
-
-		log.Infof("Checking config for %s AS%d", peerName, peerData.Asn)
-
-		// Validate peer type
-		if !(peerData.Type == "upstream" || peerData.Type == "peer" || peerData.Type == "downstream" || peerData.Type == "import-valid") {
-			log.Fatalf("    type attribute is invalid. Must be upstream, peer, downstream, or import-valid", peerName)
-		}
-
-		log.Infof("    type: %s", peerData.Type)
-
-		// Set default local pref
-		if peerData.LocalPref == 0 {
-			peerData.LocalPref = 100
-		}
-
-		// Only query PeeringDB and IRRDB for peers and downstreams
-		if peerData.Type == "peer" || peerData.Type == "downstream" {
-			peerData.QueryTime = time.Now().Format(time.RFC1123)
-			peeringDbData := getPeeringDbData(peerData.Asn)
+	if *daemonMode {
+		log.Infof("Starting in daemon mode, reconciling every %s", *refreshInterval)
 
-			if peerData.ImportLimit4 == 0 {
-				peerData.ImportLimit4 = peeringDbData.MaxPfx4
-				log.Infof("Peer %s has no IPv4 import limit configured. Setting to %d from PeeringDB", peerName, peeringDbData.MaxPfx4)
-			}
-
-			if peerData.ImportLimit6 == 0 {
-				peerData.ImportLimit6 = peeringDbData.MaxPfx6
-				log.Infof("Peer %s has no IPv6 import limit configured. Setting to %d from PeeringDB", peerName, peeringDbData.MaxPfx6)
-			}
-
-			if strings.Contains(peeringDbData.AsSet, "::") {
-				peerData.AsSet = strings.Split(peeringDbData.AsSet, "::")[1]
-			} else {
-				peerData.AsSet = peeringDbData.AsSet
-			}
-
-			peerData.PrefixSet4 = getPrefixFilter(peerData.AsSet, 4, config.IrrDb)
-			peerData.PrefixSet6 = getPrefixFilter(peerData.AsSet, 6, config.IrrDb)
-
-			// Update the "latest operation" timestamp
-			peerData.QueryTime = time.Now().Format(time.RFC1123)
-		} else if peerData.Type == "upstream" || peerData.Type == "import-valid" {
-			// Check if upstream has MaxPrefix4/6 set, if not set sensible defaults and if they are configured too low, warn the user
-			if peerData.ImportLimit4 == 0 {
-				peerData.ImportLimit4 = 1000000 // 1M routes
-				log.Infof("Upstream/Import-Valid %s has no IPv4 import limit configured. Setting to 1,000,000", peerName)
-			} else if peerData.ImportLimit4 <= 900000 {
-				log.Infof("Upstream/Import-Valid %s has a low IPv4 import limit configured. You may want to increase the import limit.", peerName)
+		daemon := NewDaemon(*refreshInterval, func(d *Daemon) *ReconcileResult {
+			cfg, err := loadAndPrepareConfig()
+			if err != nil {
+				log.Errorf("Reloading config: %v", err)
+				return &ReconcileResult{RanAt: time.Now(), Errors: []string{err.Error()}}
 			}
-
-			if peerData.ImportLimit6 == 0 {
-				peerData.ImportLimit6 = 150000 // 150k routes
-				log.Infof("Upstream/Import-Valid %s has no IPv6 import limit configured. Setting to 150,000", peerName)
-			} else if peerData.ImportLimit6 <= 98000 {
-				log.Infof("Upstream/Import-Valid %s has a low IPv6 import limit configured. You may want to increase the import limit.", peerName)
+			if store != nil {
+				cfg, err = mergeStorePeers(cfg, store)
+				if err != nil {
+					log.Errorf("Merging gRPC-managed peers: %v", err)
+					return &ReconcileResult{RanAt: time.Now(), Errors: []string{err.Error()}}
+				}
 			}
-		}
-
-		log.Infof("    local pref: %d", peerData.LocalPref)
-		log.Infof("    max prefixes: IPv4 %d, IPv6 %d", peerData.ImportLimit4, peerData.ImportLimit6)
-
-		// Check for additional options
-		if peerData.AsSet != "" {
-			log.Infof("    as-set: %s", peerData.AsSet)
-		}
-
-		if peerData.Prepends > 0 {
-			log.Infof("    prepends: %d", peerData.Prepends)
-		}
-
-		if peerData.Multihop {
-			log.Infof("    multihop")
-		}
-
-		if peerData.Passive {
-			log.Infof("    passive")
-		}
-
-		if peerData.Disabled {
-			log.Infof("    disabled")
-		}
-
-		if peerData.PreImport != "" {
-			log.Infof("    pre-import: %s", peerData.PreImport)
-		}
-
-		if peerData.PreExport != "" {
-			log.Infof("    pre-export: %s", peerData.PreExport)
-		}
-
-		// Log neighbor IPs
-		log.Infof("    neighbors:")
-		for _, ip := range peerData.NeighborIps {
-			log.Infof("      %s", ip)
-		}
-
-		if !*dryRun {
-			// Create the peer specific file
-			peerSpecificFile, err := os.Create(path.Join(*outputDirectory, "AS"+strconv.Itoa(int(peerData.Asn))+"_"+normalize(peerName)+".conf"))
-			if err != nil {
-				log.Fatalf("Create peer specific output file: %v", err)
+			result := reconcile(cfg, peerTemplate, globalTemplate, uiTemplate, d)
+			publishPeerStatus(apiServer, result)
+			return result
+		})
+
+		http.HandleFunc("/statusz", daemon.StatusZHandler)
+		go func() {
+			log.Infof("Serving /statusz on %s", *statuszListen)
+			if err := http.ListenAndServe(*statuszListen, nil); err != nil {
+				log.Errorf("statusz server: %v", err)
 			}
+		}()
 
-			// Render the template and write to disk
-			err = peerTemplate.ExecuteTemplate(peerSpecificFile, "peer.tmpl", &PeerTemplate{*peerData, config})
-			if err != nil {
-				log.Fatalf("Execute template: %v", err)
-			}
+		daemon.Run(context.Background())
+		return
+	}
 
-			log.Infof("Wrote peer specific config for AS%d", peerData.Asn)
-		} else {
-			log.Infof("Dry run is enabled, skipped writing peer config(s)")
+	if store != nil {
+		var err error
+		config, err = mergeStorePeers(config, store)
+		if err != nil {
+			log.Fatalf("Merging gRPC-managed peers: %v", err)
 		}
 	}
+	result := reconcile(config, peerTemplate, globalTemplate, uiTemplate, nil)
+	publishPeerStatus(apiServer, result)
+
+	if *grpcListen != "" {
+		// -grpc-listen is a standing service on its own, independent of
+		// -daemon: an external controller pushes peers over gRPC and
+		// expects TriggerReconcile to keep working until the process is
+		// killed. Without -daemon there's no ticker to block on, so
+		// park here instead of falling through and taking the listener
+		// down with us.
+		select {}
+	}
+}
 
-	if !*dryRun {
-		if !*noui {
-			// Create the ui output file
-			log.Debug("Creating global config")
-			uiFileObj, err := os.Create(*uiFile)
-			if err != nil {
-				log.Fatalf("Create UI output file: %v", err)
-			}
-			log.Debug("Finished creating UI file")
-
-			// Render the UI template and write to disk
-			log.Debug("Writing ui file")
-			err = uiTemplate.ExecuteTemplate(uiFileObj, "ui.tmpl", config)
-			if err != nil {
-				log.Fatalf("Execute ui template: %v", err)
-			}
-			log.Debug("Finished writing ui file")
-		}
+// publishPeerStatus fans a completed reconciliation pass out to
+// apiServer's WatchPeerStatus subscribers. apiServer is nil unless
+// -grpc-listen is set, in which case this is a no-op.
+func publishPeerStatus(apiServer *api.Server, result *ReconcileResult) {
+	if apiServer == nil {
+		return
+	}
 
-		runBirdCommand("configure")
+	for name, status := range result.Peers {
+		apiServer.PublishPeerStatus(api.PeerStatusUpdate{
+			Name:         name,
+			QueryTime:    status.QueryTime,
+			PrefixCount4: uint32(status.PrefixCount4),
+			PrefixCount6: uint32(status.PrefixCount6),
+		})
 	}
 }
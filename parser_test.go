@@ -0,0 +1,79 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/natesales/bcg/pkg/api"
+)
+
+// fakeStore is an in-memory api.Store for exercising mergeStorePeers
+// without a real BoltDB file.
+type fakeStore struct {
+	records map[string]api.PeerRecord
+}
+
+func (s *fakeStore) List() (map[string]api.PeerRecord, error) { return s.records, nil }
+func (s *fakeStore) Upsert(name string, peer api.PeerRecord) error {
+	s.records[name] = peer
+	return nil
+}
+func (s *fakeStore) Delete(name string) error {
+	delete(s.records, name)
+	return nil
+}
+
+func TestMergeStorePeersAddsStorePeers(t *testing.T) {
+	config := Config{Peers: map[string]*Peer{
+		"yaml-peer": {Asn: 64500},
+	}}
+	store := &fakeStore{records: map[string]api.PeerRecord{
+		"grpc-peer": {Asn: 64501},
+	}}
+
+	merged, err := mergeStorePeers(config, store)
+	if err != nil {
+		t.Fatalf("mergeStorePeers: %v", err)
+	}
+	if len(merged.Peers) != 2 {
+		t.Fatalf("len(merged.Peers) = %d, want 2", len(merged.Peers))
+	}
+	if merged.Peers["yaml-peer"].Asn != 64500 {
+		t.Errorf("yaml-peer.Asn = %d, want 64500", merged.Peers["yaml-peer"].Asn)
+	}
+	if merged.Peers["grpc-peer"].Asn != 64501 {
+		t.Errorf("grpc-peer.Asn = %d, want 64501", merged.Peers["grpc-peer"].Asn)
+	}
+}
+
+func TestMergeStorePeersStorePeerTakesPrecedence(t *testing.T) {
+	config := Config{Peers: map[string]*Peer{
+		"shared": {Asn: 64500},
+	}}
+	store := &fakeStore{records: map[string]api.PeerRecord{
+		"shared": {Asn: 64999},
+	}}
+
+	merged, err := mergeStorePeers(config, store)
+	if err != nil {
+		t.Fatalf("mergeStorePeers: %v", err)
+	}
+	if merged.Peers["shared"].Asn != 64999 {
+		t.Errorf("shared.Asn = %d, want 64999 (store-managed peer should win)", merged.Peers["shared"].Asn)
+	}
+}
+
+func TestMergeStorePeersDoesNotMutateInput(t *testing.T) {
+	config := Config{Peers: map[string]*Peer{
+		"yaml-peer": {Asn: 64500},
+	}}
+	store := &fakeStore{records: map[string]api.PeerRecord{
+		"grpc-peer": {Asn: 64501},
+	}}
+
+	if _, err := mergeStorePeers(config, store); err != nil {
+		t.Fatalf("mergeStorePeers: %v", err)
+	}
+	if len(config.Peers) != 1 {
+		t.Errorf("len(config.Peers) = %d, want 1 (input map must be left untouched)", len(config.Peers))
+	}
+}
@@ -0,0 +1,33 @@
+package api
+
+import (
+	"fmt"
+
+	"github.com/golang/protobuf/proto"
+)
+
+// protoCodec exchanges the message types in peering.pb.go over the
+// gRPC wire as actual protobuf binary framing. Those types don't come
+// from a protoc-gen-go run (this tree has no protoc step yet), but
+// they carry real `protobuf:` struct tags and satisfy proto.Message,
+// so proto.Marshal/Unmarshal encode them exactly as a protoc-generated
+// client would expect.
+type protoCodec struct{}
+
+func (protoCodec) Marshal(v interface{}) ([]byte, error) {
+	msg, ok := v.(proto.Message)
+	if !ok {
+		return nil, fmt.Errorf("protoCodec: %T does not implement proto.Message", v)
+	}
+	return proto.Marshal(msg)
+}
+
+func (protoCodec) Unmarshal(data []byte, v interface{}) error {
+	msg, ok := v.(proto.Message)
+	if !ok {
+		return fmt.Errorf("protoCodec: %T does not implement proto.Message", v)
+	}
+	return proto.Unmarshal(data, msg)
+}
+
+func (protoCodec) Name() string { return "proto" }
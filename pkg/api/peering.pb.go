@@ -0,0 +1,116 @@
+package api
+
+import "fmt"
+
+// Message types below are hand-maintained to mirror the wire shape
+// protoc-gen-go would emit from proto/peering.proto: struct fields
+// carry real `protobuf:` tags, and each type implements the classic
+// proto.Message interface (Reset/String/ProtoMessage) so protoCodec
+// can marshal them with actual protobuf binary framing instead of
+// JSON. Keep this file in sync with proto/peering.proto by hand until
+// this tree grows a protoc step.
+
+// PeerRecord is a gRPC-managed peer, mirroring the fields of
+// config.yml's `peers` map that an external controller is allowed to
+// set directly.
+type PeerRecord struct {
+	Asn          uint32   `protobuf:"varint,1,opt,name=asn,proto3" json:"asn,omitempty"`
+	Type         string   `protobuf:"bytes,2,opt,name=type,proto3" json:"type,omitempty"`
+	Prepends     uint32   `protobuf:"varint,3,opt,name=prepends,proto3" json:"prepends,omitempty"`
+	LocalPref    uint32   `protobuf:"varint,4,opt,name=local_pref,json=localPref,proto3" json:"local_pref,omitempty"`
+	Multihop     bool     `protobuf:"varint,5,opt,name=multihop,proto3" json:"multihop,omitempty"`
+	Passive      bool     `protobuf:"varint,6,opt,name=passive,proto3" json:"passive,omitempty"`
+	Disabled     bool     `protobuf:"varint,7,opt,name=disabled,proto3" json:"disabled,omitempty"`
+	Password     string   `protobuf:"bytes,8,opt,name=password,proto3" json:"password,omitempty"`
+	Port         uint32   `protobuf:"varint,9,opt,name=port,proto3" json:"port,omitempty"`
+	PreImport    string   `protobuf:"bytes,10,opt,name=pre_import,json=preImport,proto3" json:"pre_import,omitempty"`
+	PreExport    string   `protobuf:"bytes,11,opt,name=pre_export,json=preExport,proto3" json:"pre_export,omitempty"`
+	Neighbors    []string `protobuf:"bytes,12,rep,name=neighbors,proto3" json:"neighbors,omitempty"`
+	ImportLimit4 uint32   `protobuf:"varint,13,opt,name=import_limit4,json=importLimit4,proto3" json:"import_limit4,omitempty"`
+	ImportLimit6 uint32   `protobuf:"varint,14,opt,name=import_limit6,json=importLimit6,proto3" json:"import_limit6,omitempty"`
+	SkipFilter   bool     `protobuf:"varint,15,opt,name=skip_filter,json=skipFilter,proto3" json:"skip_filter,omitempty"`
+	RsClient     bool     `protobuf:"varint,16,opt,name=rs_client,json=rsClient,proto3" json:"rs_client,omitempty"`
+	RrClient     bool     `protobuf:"varint,17,opt,name=rr_client,json=rrClient,proto3" json:"rr_client,omitempty"`
+	Bfd          bool     `protobuf:"varint,18,opt,name=bfd,proto3" json:"bfd,omitempty"`
+}
+
+func (m *PeerRecord) Reset()         { *m = PeerRecord{} }
+func (m *PeerRecord) String() string { return fmt.Sprintf("%+v", *m) }
+func (*PeerRecord) ProtoMessage()    {}
+
+type ListPeersRequest struct{}
+
+func (m *ListPeersRequest) Reset()         { *m = ListPeersRequest{} }
+func (m *ListPeersRequest) String() string { return fmt.Sprintf("%+v", *m) }
+func (*ListPeersRequest) ProtoMessage()    {}
+
+type ListPeersResponse struct {
+	Peers map[string]*PeerRecord `protobuf:"bytes,1,rep,name=peers,proto3" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3" json:"peers,omitempty"`
+}
+
+func (m *ListPeersResponse) Reset()         { *m = ListPeersResponse{} }
+func (m *ListPeersResponse) String() string { return fmt.Sprintf("%+v", *m) }
+func (*ListPeersResponse) ProtoMessage()    {}
+
+type UpsertPeerRequest struct {
+	Name string      `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Peer *PeerRecord `protobuf:"bytes,2,opt,name=peer,proto3" json:"peer,omitempty"`
+}
+
+func (m *UpsertPeerRequest) Reset()         { *m = UpsertPeerRequest{} }
+func (m *UpsertPeerRequest) String() string { return fmt.Sprintf("%+v", *m) }
+func (*UpsertPeerRequest) ProtoMessage()    {}
+
+type UpsertPeerResponse struct {
+	Peer *PeerRecord `protobuf:"bytes,1,opt,name=peer,proto3" json:"peer,omitempty"`
+}
+
+func (m *UpsertPeerResponse) Reset()         { *m = UpsertPeerResponse{} }
+func (m *UpsertPeerResponse) String() string { return fmt.Sprintf("%+v", *m) }
+func (*UpsertPeerResponse) ProtoMessage()    {}
+
+type DeletePeerRequest struct {
+	Name string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+}
+
+func (m *DeletePeerRequest) Reset()         { *m = DeletePeerRequest{} }
+func (m *DeletePeerRequest) String() string { return fmt.Sprintf("%+v", *m) }
+func (*DeletePeerRequest) ProtoMessage()    {}
+
+type DeletePeerResponse struct{}
+
+func (m *DeletePeerResponse) Reset()         { *m = DeletePeerResponse{} }
+func (m *DeletePeerResponse) String() string { return fmt.Sprintf("%+v", *m) }
+func (*DeletePeerResponse) ProtoMessage()    {}
+
+type TriggerReconcileRequest struct{}
+
+func (m *TriggerReconcileRequest) Reset()         { *m = TriggerReconcileRequest{} }
+func (m *TriggerReconcileRequest) String() string { return fmt.Sprintf("%+v", *m) }
+func (*TriggerReconcileRequest) ProtoMessage()    {}
+
+type TriggerReconcileResponse struct {
+	Reconfigured bool     `protobuf:"varint,1,opt,name=reconfigured,proto3" json:"reconfigured,omitempty"`
+	Errors       []string `protobuf:"bytes,2,rep,name=errors,proto3" json:"errors,omitempty"`
+}
+
+func (m *TriggerReconcileResponse) Reset()         { *m = TriggerReconcileResponse{} }
+func (m *TriggerReconcileResponse) String() string { return fmt.Sprintf("%+v", *m) }
+func (*TriggerReconcileResponse) ProtoMessage()    {}
+
+type WatchPeerStatusRequest struct{}
+
+func (m *WatchPeerStatusRequest) Reset()         { *m = WatchPeerStatusRequest{} }
+func (m *WatchPeerStatusRequest) String() string { return fmt.Sprintf("%+v", *m) }
+func (*WatchPeerStatusRequest) ProtoMessage()    {}
+
+type PeerStatusUpdate struct {
+	Name         string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	QueryTime    string `protobuf:"bytes,2,opt,name=query_time,json=queryTime,proto3" json:"query_time,omitempty"`
+	PrefixCount4 uint32 `protobuf:"varint,3,opt,name=prefix_count4,json=prefixCount4,proto3" json:"prefix_count4,omitempty"`
+	PrefixCount6 uint32 `protobuf:"varint,4,opt,name=prefix_count6,json=prefixCount6,proto3" json:"prefix_count6,omitempty"`
+}
+
+func (m *PeerStatusUpdate) Reset()         { *m = PeerStatusUpdate{} }
+func (m *PeerStatusUpdate) String() string { return fmt.Sprintf("%+v", *m) }
+func (*PeerStatusUpdate) ProtoMessage()    {}
@@ -0,0 +1,132 @@
+package api
+
+import (
+	"context"
+	"sync"
+
+	"google.golang.org/grpc"
+)
+
+// Reconciler lets TriggerReconcile run the same generate-and-configure
+// pass the daemon runs on its own ticker.
+type Reconciler interface {
+	Reconcile() (reconfigured bool, errs []string)
+}
+
+// Server implements the Peering gRPC service against a Store, and fans
+// out peer status updates to WatchPeerStatus subscribers.
+type Server struct {
+	store      Store
+	reconciler Reconciler
+
+	mu   sync.Mutex
+	subs map[chan PeerStatusUpdate]struct{}
+}
+
+// NewServer builds a Server backed by store. reconciler may be nil, in
+// which case TriggerReconcile is a no-op.
+func NewServer(store Store, reconciler Reconciler) *Server {
+	return &Server{
+		store:      store,
+		reconciler: reconciler,
+		subs:       map[chan PeerStatusUpdate]struct{}{},
+	}
+}
+
+// NewGRPCServer builds a *grpc.Server with the Peering service
+// registered against srv, using protoCodec so peers and RPC
+// request/response messages are exchanged as real protobuf binary,
+// matching proto/peering.proto's wire format.
+func NewGRPCServer(srv PeeringServer) *grpc.Server {
+	s := grpc.NewServer(grpc.ForceServerCodec(protoCodec{}))
+	s.RegisterService(&ServiceDesc, srv)
+	return s
+}
+
+func (s *Server) ListPeers(ctx context.Context, req *ListPeersRequest) (*ListPeersResponse, error) {
+	peers, err := s.store.List()
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &ListPeersResponse{Peers: make(map[string]*PeerRecord, len(peers))}
+	for name, peer := range peers {
+		peer := peer
+		resp.Peers[name] = &peer
+	}
+	return resp, nil
+}
+
+func (s *Server) UpsertPeer(ctx context.Context, req *UpsertPeerRequest) (*UpsertPeerResponse, error) {
+	var peer PeerRecord
+	if req.Peer != nil {
+		peer = *req.Peer
+	}
+	if err := s.store.Upsert(req.Name, peer); err != nil {
+		return nil, err
+	}
+	return &UpsertPeerResponse{Peer: &peer}, nil
+}
+
+func (s *Server) DeletePeer(ctx context.Context, req *DeletePeerRequest) (*DeletePeerResponse, error) {
+	if err := s.store.Delete(req.Name); err != nil {
+		return nil, err
+	}
+	return &DeletePeerResponse{}, nil
+}
+
+func (s *Server) TriggerReconcile(ctx context.Context, req *TriggerReconcileRequest) (*TriggerReconcileResponse, error) {
+	if s.reconciler == nil {
+		return &TriggerReconcileResponse{}, nil
+	}
+	reconfigured, errs := s.reconciler.Reconcile()
+	return &TriggerReconcileResponse{Reconfigured: reconfigured, Errors: errs}, nil
+}
+
+// PublishPeerStatus fans a status update out to every active
+// WatchPeerStatus subscriber. Callers (e.g. the daemon, after each
+// reconciliation pass) should not block on this.
+func (s *Server) PublishPeerStatus(update PeerStatusUpdate) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for ch := range s.subs {
+		select {
+		case ch <- update:
+		default:
+			// Slow subscriber; drop rather than block reconciliation.
+		}
+	}
+}
+
+func (s *Server) subscribe() chan PeerStatusUpdate {
+	ch := make(chan PeerStatusUpdate, 16)
+	s.mu.Lock()
+	s.subs[ch] = struct{}{}
+	s.mu.Unlock()
+	return ch
+}
+
+func (s *Server) unsubscribe(ch chan PeerStatusUpdate) {
+	s.mu.Lock()
+	delete(s.subs, ch)
+	s.mu.Unlock()
+	close(ch)
+}
+
+// WatchPeerStatus streams PeerStatusUpdates to the caller until the
+// stream's context is canceled.
+func (s *Server) WatchPeerStatus(req *WatchPeerStatusRequest, stream grpc.ServerStream) error {
+	ch := s.subscribe()
+	defer s.unsubscribe(ch)
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return stream.Context().Err()
+		case update := <-ch:
+			if err := stream.SendMsg(&update); err != nil {
+				return err
+			}
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package api
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+)
+
+// PeeringServer is implemented by Server; it's the interface
+// protoc-gen-go-grpc would normally emit from proto/peering.proto.
+type PeeringServer interface {
+	ListPeers(context.Context, *ListPeersRequest) (*ListPeersResponse, error)
+	UpsertPeer(context.Context, *UpsertPeerRequest) (*UpsertPeerResponse, error)
+	DeletePeer(context.Context, *DeletePeerRequest) (*DeletePeerResponse, error)
+	TriggerReconcile(context.Context, *TriggerReconcileRequest) (*TriggerReconcileResponse, error)
+	WatchPeerStatus(*WatchPeerStatusRequest, grpc.ServerStream) error
+}
+
+// ServiceDesc is the grpc.ServiceDesc for Peering, hand-wired against
+// protoCodec since this tree has no protoc step to generate one.
+var ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "bcg.api.v1.Peering",
+	HandlerType: (*PeeringServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{MethodName: "ListPeers", Handler: _Peering_ListPeers_Handler},
+		{MethodName: "UpsertPeer", Handler: _Peering_UpsertPeer_Handler},
+		{MethodName: "DeletePeer", Handler: _Peering_DeletePeer_Handler},
+		{MethodName: "TriggerReconcile", Handler: _Peering_TriggerReconcile_Handler},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchPeerStatus",
+			Handler:       _Peering_WatchPeerStatus_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "proto/peering.proto",
+}
+
+func _Peering_ListPeers_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListPeersRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PeeringServer).ListPeers(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{Server: srv, FullMethod: "/bcg.api.v1.Peering/ListPeers"}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PeeringServer).ListPeers(ctx, req.(*ListPeersRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Peering_UpsertPeer_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpsertPeerRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PeeringServer).UpsertPeer(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{Server: srv, FullMethod: "/bcg.api.v1.Peering/UpsertPeer"}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PeeringServer).UpsertPeer(ctx, req.(*UpsertPeerRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Peering_DeletePeer_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeletePeerRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PeeringServer).DeletePeer(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{Server: srv, FullMethod: "/bcg.api.v1.Peering/DeletePeer"}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PeeringServer).DeletePeer(ctx, req.(*DeletePeerRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Peering_TriggerReconcile_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TriggerReconcileRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PeeringServer).TriggerReconcile(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{Server: srv, FullMethod: "/bcg.api.v1.Peering/TriggerReconcile"}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PeeringServer).TriggerReconcile(ctx, req.(*TriggerReconcileRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Peering_WatchPeerStatus_Handler(srv interface{}, stream grpc.ServerStream) error {
+	in := new(WatchPeerStatusRequest)
+	if err := stream.RecvMsg(in); err != nil {
+		return err
+	}
+	return srv.(PeeringServer).WatchPeerStatus(in, stream)
+}
@@ -0,0 +1,93 @@
+// Package api implements the gRPC peering service described in
+// proto/peering.proto: a pluggable peer store plus the server glue that
+// lets an external controller manage peers and trigger reconciliation
+// without a YAML config file.
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+var peersBucket = []byte("peers")
+
+// Store persists the peers managed through the gRPC API. PeerRecord
+// (defined in peering.pb.go) is reused here as the storage shape so
+// there's no translation layer between what's pushed over gRPC and
+// what's persisted.
+type Store interface {
+	List() (map[string]PeerRecord, error)
+	Upsert(name string, peer PeerRecord) error
+	Delete(name string) error
+}
+
+// BoltStore is a Store backed by a local BoltDB file. It's the default
+// store used when -grpc-listen is set, since bcg otherwise has no
+// database of its own to reuse.
+type BoltStore struct {
+	db *bolt.DB
+}
+
+// NewBoltStore opens (creating if necessary) a BoltDB file at path and
+// ensures the peers bucket exists.
+func NewBoltStore(path string) (*BoltStore, error) {
+	db, err := bolt.Open(path, 0600, nil)
+	if err != nil {
+		return nil, fmt.Errorf("open bolt store %s: %v", path, err)
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(peersBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("init peers bucket: %v", err)
+	}
+
+	return &BoltStore{db: db}, nil
+}
+
+// Close closes the underlying BoltDB file.
+func (s *BoltStore) Close() error {
+	return s.db.Close()
+}
+
+// List returns every peer currently in the store, keyed by name.
+func (s *BoltStore) List() (map[string]PeerRecord, error) {
+	peers := map[string]PeerRecord{}
+
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(peersBucket).ForEach(func(k, v []byte) error {
+			var peer PeerRecord
+			if err := json.Unmarshal(v, &peer); err != nil {
+				return fmt.Errorf("decode peer %s: %v", k, err)
+			}
+			peers[string(k)] = peer
+			return nil
+		})
+	})
+
+	return peers, err
+}
+
+// Upsert creates or replaces the peer named name.
+func (s *BoltStore) Upsert(name string, peer PeerRecord) error {
+	data, err := json.Marshal(peer)
+	if err != nil {
+		return fmt.Errorf("encode peer %s: %v", name, err)
+	}
+
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(peersBucket).Put([]byte(name), data)
+	})
+}
+
+// Delete removes the peer named name, if it exists.
+func (s *BoltStore) Delete(name string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(peersBucket).Delete([]byte(name))
+	})
+}
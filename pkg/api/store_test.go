@@ -0,0 +1,86 @@
+package api
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func newTestStore(t *testing.T) *BoltStore {
+	t.Helper()
+
+	store, err := NewBoltStore(filepath.Join(t.TempDir(), "api.db"))
+	if err != nil {
+		t.Fatalf("NewBoltStore: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+
+	return store
+}
+
+func TestBoltStoreUpsertAndList(t *testing.T) {
+	store := newTestStore(t)
+
+	if err := store.Upsert("peer-a", PeerRecord{Asn: 64500}); err != nil {
+		t.Fatalf("Upsert: %v", err)
+	}
+
+	peers, err := store.List()
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(peers) != 1 {
+		t.Fatalf("len(peers) = %d, want 1", len(peers))
+	}
+	if peers["peer-a"].Asn != 64500 {
+		t.Errorf("peer-a.Asn = %d, want 64500", peers["peer-a"].Asn)
+	}
+}
+
+func TestBoltStoreUpsertReplacesExisting(t *testing.T) {
+	store := newTestStore(t)
+
+	if err := store.Upsert("peer-a", PeerRecord{Asn: 64500}); err != nil {
+		t.Fatalf("Upsert: %v", err)
+	}
+	if err := store.Upsert("peer-a", PeerRecord{Asn: 64999}); err != nil {
+		t.Fatalf("Upsert: %v", err)
+	}
+
+	peers, err := store.List()
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(peers) != 1 {
+		t.Fatalf("len(peers) = %d, want 1", len(peers))
+	}
+	if peers["peer-a"].Asn != 64999 {
+		t.Errorf("peer-a.Asn = %d, want 64999 after replacing", peers["peer-a"].Asn)
+	}
+}
+
+func TestBoltStoreDelete(t *testing.T) {
+	store := newTestStore(t)
+
+	if err := store.Upsert("peer-a", PeerRecord{Asn: 64500}); err != nil {
+		t.Fatalf("Upsert: %v", err)
+	}
+	if err := store.Delete("peer-a"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+
+	peers, err := store.List()
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(peers) != 0 {
+		t.Errorf("len(peers) = %d, want 0 after delete", len(peers))
+	}
+}
+
+func TestBoltStoreDeleteMissingPeerIsNotAnError(t *testing.T) {
+	store := newTestStore(t)
+
+	if err := store.Delete("never-existed"); err != nil {
+		t.Errorf("Delete of a missing peer should be a no-op, got: %v", err)
+	}
+}
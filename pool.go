@@ -0,0 +1,46 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// forEachPeer runs fn for every peer in peers, bounded to at most
+// concurrency goroutines at a time, with each call given its own
+// timeout derived from ctx. It waits for all peers to finish before
+// returning, with every error tagged by the peer name that produced
+// it.
+func forEachPeer(ctx context.Context, peers map[string]*Peer, concurrency int, timeout time.Duration, fn func(ctx context.Context, name string, peer *Peer) error) []error {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var errs []error
+
+	for name, peer := range peers {
+		wg.Add(1)
+		sem <- struct{}{}
+
+		go func(name string, peer *Peer) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			peerCtx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+
+			if err := fn(peerCtx, name, peer); err != nil {
+				mu.Lock()
+				errs = append(errs, fmt.Errorf("%s: %w", name, err))
+				mu.Unlock()
+			}
+		}(name, peer)
+	}
+
+	wg.Wait()
+	return errs
+}
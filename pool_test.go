@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestForEachPeerBoundsConcurrency(t *testing.T) {
+	peers := map[string]*Peer{}
+	for i := 0; i < 10; i++ {
+		peers[fmt.Sprintf("peer%d", i)] = &Peer{}
+	}
+
+	const concurrency = 3
+	var inFlight, maxInFlight int32
+
+	errs := forEachPeer(context.Background(), peers, concurrency, time.Second, func(ctx context.Context, name string, peer *Peer) error {
+		n := atomic.AddInt32(&inFlight, 1)
+		defer atomic.AddInt32(&inFlight, -1)
+
+		for {
+			cur := atomic.LoadInt32(&maxInFlight)
+			if n <= cur || atomic.CompareAndSwapInt32(&maxInFlight, cur, n) {
+				break
+			}
+		}
+
+		time.Sleep(10 * time.Millisecond)
+		return nil
+	})
+
+	if len(errs) != 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	if maxInFlight > concurrency {
+		t.Errorf("observed %d goroutines in flight, want at most %d", maxInFlight, concurrency)
+	}
+}
+
+func TestForEachPeerAggregatesErrorsByName(t *testing.T) {
+	peers := map[string]*Peer{
+		"good": {},
+		"bad":  {},
+	}
+
+	errs := forEachPeer(context.Background(), peers, 2, time.Second, func(ctx context.Context, name string, peer *Peer) error {
+		if name == "bad" {
+			return fmt.Errorf("boom")
+		}
+		return nil
+	})
+
+	if len(errs) != 1 {
+		t.Fatalf("len(errs) = %d, want 1", len(errs))
+	}
+	if got := errs[0].Error(); got != "bad: boom" {
+		t.Errorf("errs[0] = %q, want %q", got, "bad: boom")
+	}
+}
+
+func TestForEachPeerRunsEveryPeer(t *testing.T) {
+	peers := map[string]*Peer{}
+	for i := 0; i < 25; i++ {
+		peers[fmt.Sprintf("peer%d", i)] = &Peer{}
+	}
+
+	var mu sync.Mutex
+	seen := map[string]bool{}
+
+	forEachPeer(context.Background(), peers, 4, time.Second, func(ctx context.Context, name string, peer *Peer) error {
+		mu.Lock()
+		seen[name] = true
+		mu.Unlock()
+		return nil
+	})
+
+	if len(seen) != len(peers) {
+		t.Errorf("fn ran for %d peers, want %d", len(seen), len(peers))
+	}
+}
+
+func TestForEachPeerZeroConcurrencyStillRuns(t *testing.T) {
+	peers := map[string]*Peer{"only": {}}
+
+	var ran bool
+	errs := forEachPeer(context.Background(), peers, 0, time.Second, func(ctx context.Context, name string, peer *Peer) error {
+		ran = true
+		return nil
+	})
+
+	if len(errs) != 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	if !ran {
+		t.Error("fn never ran with concurrency <= 0, want it clamped to 1")
+	}
+}
@@ -0,0 +1,19 @@
+package main
+
+import "context"
+
+// Processor is one stage of the enrichment pipeline that the
+// Orchestrator runs against the shared State, e.g. resolving PeeringDB
+// metadata or generating IRR prefix filters for a peer. Splitting each
+// stage behind this interface lets new ones (RPKI validation, secret
+// generation, AS-cone limits, ...) be added without touching main, and
+// lets each be tested in isolation with a mock State.
+type Processor interface {
+	// Name identifies the processor in logs and error messages.
+	Name() string
+
+	// Run enriches or validates state.Config.Peers. A returned error
+	// is recorded by the Orchestrator; it does not stop the rest of
+	// the pipeline from running.
+	Run(ctx context.Context, state *State) error
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// IRRProcessor generates BIRD prefix filters for each peer's as-set via
+// bgpq4, fanned out over the same bounded worker pool as
+// PeeringDBProcessor. It runs after PeeringDBProcessor so it can rely
+// on AsSet already being resolved.
+type IRRProcessor struct{}
+
+func (p *IRRProcessor) Name() string { return "irr" }
+
+func (p *IRRProcessor) Run(ctx context.Context, state *State) error {
+	errs := forEachPeer(ctx, state.Config.Peers, *concurrency, *peerTimeout, func(ctx context.Context, peerName string, peerData *Peer) error {
+		if peerData.Type != "peer" && peerData.Type != "downstream" {
+			return nil
+		}
+		if peerData.AsSet == "" {
+			return nil
+		}
+
+		prefixSet4, err := getPrefixFilter(ctx, peerData.AsSet, 4, state.Config.IrrDb)
+		if err != nil {
+			return err
+		}
+
+		prefixSet6, err := getPrefixFilter(ctx, peerData.AsSet, 6, state.Config.IrrDb)
+		if err != nil {
+			return err
+		}
+
+		peerData.PrefixSet4 = prefixSet4
+		peerData.PrefixSet6 = prefixSet6
+		return nil
+	})
+
+	if len(errs) > 0 {
+		return fmt.Errorf("%d peer(s) failed IRR filter generation: %v", len(errs), errs)
+	}
+
+	return nil
+}
+
+// Use bgpq4 to generate a prefix filter and return only the filter lines
+func getPrefixFilter(ctx context.Context, asSet string, family uint8, irrdb string) ([]string, error) {
+	// Run bgpq4 for BIRD format with aggregation enabled
+	log.Infof("Running bgpq4 -h %s -Ab%d %s", irrdb, family, asSet)
+	cmd := exec.CommandContext(ctx, "bgpq4", "-h", irrdb, "-Ab"+strconv.Itoa(int(family)), asSet)
+	stdout, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("bgpq4 error: %v", err)
+	}
+
+	// Remove whitespace and commas from output
+	output := strings.ReplaceAll(string(stdout), ",\n    ", "\n")
+
+	// Remove array prefix
+	output = strings.ReplaceAll(output, "NN = [\n    ", "")
+
+	// Remove array suffix
+	output = strings.ReplaceAll(output, "];", "")
+
+	// Check for empty IRR
+	if output == "" {
+		log.Warnf("Peer with as-set %s has no IPv%d prefixes. Disabled IPv%d connectivity.", asSet, family, family)
+		return []string{}, nil
+	}
+
+	// Remove whitespace (in this case there should only be trailing whitespace)
+	output = strings.TrimSpace(output)
+
+	// Split output by newline
+	return strings.Split(output, "\n"), nil
+}
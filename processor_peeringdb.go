@@ -0,0 +1,126 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// peeringDBStatusError records a non-200 PeeringDB response so callers
+// can tell a transient 5xx (worth retrying) from a permanent 4xx.
+type peeringDBStatusError struct {
+	StatusCode int
+}
+
+func (e *peeringDBStatusError) Error() string {
+	return fmt.Sprintf("PeeringDB returned HTTP %d", e.StatusCode)
+}
+
+// PeeringDBProcessor resolves each peer's PeeringDB entry and uses it to
+// fill in import limits and the as-set when they aren't already set in
+// the config. Peers are enriched concurrently over a bounded worker
+// pool; a peer whose lookup keeps failing after retries falls back to
+// its last cached result under -cache-dir instead of failing the run.
+type PeeringDBProcessor struct{}
+
+func (p *PeeringDBProcessor) Name() string { return "peeringdb" }
+
+func (p *PeeringDBProcessor) Run(ctx context.Context, state *State) error {
+	errs := forEachPeer(ctx, state.Config.Peers, *concurrency, *peerTimeout, func(ctx context.Context, peerName string, peerData *Peer) error {
+		if peerData.Type != "peer" && peerData.Type != "downstream" {
+			return nil
+		}
+
+		var peeringDbData PeeringDbData
+		err := withRetry(3, isTransientPeeringDBError, func() error {
+			var err error
+			peeringDbData, err = getPeeringDbData(ctx, peerData.Asn)
+			return err
+		})
+
+		if err != nil {
+			cached, cacheErr := loadCachedPeeringDBResult(peerData.Asn)
+			if cacheErr != nil {
+				return err
+			}
+			log.Warnf("%s: PeeringDB lookup failed (%v), falling back to cached result", peerName, err)
+			peeringDbData = cached
+		} else {
+			cachePeeringDBResult(peerData.Asn, peeringDbData)
+		}
+
+		peerData.PeeringDbMaxPfx4 = peeringDbData.MaxPfx4
+		peerData.PeeringDbMaxPfx6 = peeringDbData.MaxPfx6
+
+		if peerData.ImportLimit4 == 0 {
+			peerData.ImportLimit4 = peeringDbData.MaxPfx4
+			log.Infof("Peer %s has no IPv4 import limit configured. Setting to %d from PeeringDB", peerName, peeringDbData.MaxPfx4)
+		}
+
+		if peerData.ImportLimit6 == 0 {
+			peerData.ImportLimit6 = peeringDbData.MaxPfx6
+			log.Infof("Peer %s has no IPv6 import limit configured. Setting to %d from PeeringDB", peerName, peeringDbData.MaxPfx6)
+		}
+
+		if strings.Contains(peeringDbData.AsSet, "::") {
+			peerData.AsSet = strings.Split(peeringDbData.AsSet, "::")[1]
+		} else {
+			peerData.AsSet = peeringDbData.AsSet
+		}
+
+		peerData.QueryTime = time.Now().Format(time.RFC1123)
+		return nil
+	})
+
+	if len(errs) > 0 {
+		return fmt.Errorf("%d peer(s) failed PeeringDB lookup: %v", len(errs), errs)
+	}
+
+	return nil
+}
+
+// Query PeeringDB for an ASN
+func getPeeringDbData(ctx context.Context, asn uint) (PeeringDbData, error) {
+	httpClient := http.Client{Timeout: time.Second * 5}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "https://peeringdb.com/api/net?asn="+strconv.Itoa(int(asn)), nil)
+	if err != nil {
+		return PeeringDbData{}, fmt.Errorf("PeeringDB GET (this peer might not have a PeeringDB page): %v", err)
+	}
+
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return PeeringDbData{}, fmt.Errorf("PeeringDB GET request: %w", err)
+	}
+
+	if res.Body != nil {
+		//noinspection GoUnhandledErrorResult
+		defer res.Body.Close()
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return PeeringDbData{}, &peeringDBStatusError{StatusCode: res.StatusCode}
+	}
+
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return PeeringDbData{}, fmt.Errorf("PeeringDB read: %v", err)
+	}
+
+	var peeringDbResponse PeeringDbResponse
+	if err := json.Unmarshal(body, &peeringDbResponse); err != nil {
+		return PeeringDbData{}, fmt.Errorf("PeeringDB JSON unmarshal: %v", err)
+	}
+
+	if len(peeringDbResponse.Data) < 1 {
+		return PeeringDbData{}, fmt.Errorf("AS%d doesn't have a valid PeeringDB entry. Try import-valid or ask the network to update their account", asn)
+	}
+
+	return peeringDbResponse.Data[0], nil
+}
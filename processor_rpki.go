@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// RPKIProcessor validates every peer's IRR-derived prefix list against
+// RPKI, dropping Invalid entries and recording each entry's verdict on
+// the Peer so templates can render RpkiValid/RpkiInvalid/RpkiUnknown.
+// It runs after IRRProcessor, which is what populates PrefixSet4/6.
+type RPKIProcessor struct{}
+
+func (p *RPKIProcessor) Name() string { return "rpki" }
+
+func (p *RPKIProcessor) Run(ctx context.Context, state *State) error {
+	if *rpkiSource == "" {
+		log.Debug("No -rpki-source configured, skipping RPKI validation")
+		return nil
+	}
+
+	vrps, err := loadVRPs(ctx, *rpkiSource)
+	if err != nil {
+		return fmt.Errorf("loading VRPs from %s: %v", *rpkiSource, err)
+	}
+	log.Infof("Loaded %d VRP(s) from %s", len(vrps), *rpkiSource)
+
+	trie := newRPKITrie()
+	for _, v := range vrps {
+		trie.insert(v)
+	}
+
+	for peerName, peerData := range state.Config.Peers {
+		peerData.PrefixSet4 = p.classify(peerName, peerData, peerData.PrefixSet4, trie)
+		peerData.PrefixSet6 = p.classify(peerName, peerData, peerData.PrefixSet6, trie)
+	}
+
+	return nil
+}
+
+// classify partitions a peer's BIRD prefix list entries against the
+// RPKI trie, dropping Invalid entries from the returned list and
+// appending every entry to the matching Rpki* slice on peerData for
+// template rendering.
+func (p *RPKIProcessor) classify(peerName string, peerData *Peer, entries []string, trie *rpkiTrie) []string {
+	var kept []string
+	dropped := 0
+
+	for _, entry := range entries {
+		network, err := parseBirdPrefixEntry(entry)
+		if err != nil {
+			log.Warnf("%s: %v, keeping unvalidated", peerName, err)
+			kept = append(kept, entry)
+			continue
+		}
+
+		switch trie.classify(network, uint32(peerData.Asn)) {
+		case rpkiValid:
+			peerData.RpkiValid = append(peerData.RpkiValid, entry)
+			kept = append(kept, entry)
+		case rpkiInvalid:
+			peerData.RpkiInvalid = append(peerData.RpkiInvalid, entry)
+			dropped++
+		default:
+			peerData.RpkiUnknown = append(peerData.RpkiUnknown, entry)
+			kept = append(kept, entry)
+		}
+	}
+
+	if dropped > 0 {
+		log.Warnf("%s: dropped %d RPKI-invalid prefix(es)", peerName, dropped)
+	}
+
+	return kept
+}
+
+// parseBirdPrefixEntry extracts the network and prefix length from a
+// BIRD-formatted prefix list line such as "192.0.2.0/24" or the
+// range-matching form bgpq4 emits, "192.0.2.0/24{24,32}". RPKI
+// validation is performed against the line's own prefix length; the
+// {min,max} suffix (if present) is filter syntax, not a route.
+func parseBirdPrefixEntry(entry string) (*net.IPNet, error) {
+	cidr := entry
+	if idx := strings.Index(entry, "{"); idx != -1 {
+		cidr = entry[:idx]
+	}
+	cidr = strings.TrimSpace(cidr)
+
+	_, network, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return nil, fmt.Errorf("parsing prefix entry %q: %v", entry, err)
+	}
+
+	return network, nil
+}
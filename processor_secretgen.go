@@ -0,0 +1,167 @@
+package main
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// SecretGenProcessor derives a per-peer TCP-MD5 BGP password from a
+// locally configured HMAC key, the peer's ASN and neighbor IP, and a
+// per-peer generation counter, for any peer with password: "auto" or
+// password-generate: true. The plaintext is written to a mode-0600
+// sidecar file under -secret-dir; templates read it back transparently
+// through Peer.Password, which never returns the literal "auto".
+type SecretGenProcessor struct{}
+
+func (p *SecretGenProcessor) Name() string { return "secretgen" }
+
+func (p *SecretGenProcessor) Run(ctx context.Context, state *State) error {
+	var generated bool
+	for _, peerData := range state.Config.Peers {
+		if peerData.PasswordConfig == "auto" || peerData.PasswordGenerate {
+			generated = true
+			break
+		}
+	}
+	if !generated {
+		return nil
+	}
+
+	if err := os.MkdirAll(*secretDir, 0700); err != nil {
+		return fmt.Errorf("create -secret-dir %s: %v", *secretDir, err)
+	}
+
+	key, err := loadSecretKey(*secretKey)
+	if err != nil {
+		return err
+	}
+
+	var errs []string
+	for peerName, peerData := range state.Config.Peers {
+		if peerData.PasswordConfig != "auto" && !peerData.PasswordGenerate {
+			continue
+		}
+
+		generation, err := secretGeneration(peerData.Asn, peerName)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", peerName, err))
+			continue
+		}
+
+		if *rotateSecrets {
+			generation++
+			if err := writeSecretGeneration(peerData.Asn, peerName, generation); err != nil {
+				errs = append(errs, fmt.Sprintf("%s: %v", peerName, err))
+				continue
+			}
+			log.Infof("Rotated generated password for %s to generation %d", peerName, generation)
+		}
+
+		secret := derivePeerSecret(key, peerData.Asn, peerData.NeighborIps, generation)
+		file := secretFilePath(peerData.Asn, peerName)
+		if err := ioutil.WriteFile(file, []byte(secret), 0600); err != nil {
+			errs = append(errs, fmt.Sprintf("%s: write secret file: %v", peerName, err))
+			continue
+		}
+
+		peerData.PasswordFile = file
+		log.Infof("Generated password for %s at %s", peerName, file)
+	}
+
+	// -rotate-secrets is a one-shot trigger, not a standing mode: once
+	// every generated peer has rotated cleanly, consume it so the next
+	// -daemon tick (or a later -grpc-listen TriggerReconcile) doesn't
+	// bump the generation counter again and break already-established
+	// BGP sessions. Leave it set to retry on the next run if any peer
+	// failed.
+	if *rotateSecrets {
+		if len(errs) == 0 {
+			*rotateSecrets = false
+			log.Info("Rotated all generated peer passwords; -rotate-secrets consumed until set again")
+		} else {
+			log.Warnf("Secret rotation had %d error(s); leaving -rotate-secrets set to retry next run", len(errs))
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("%d peer(s) failed secret generation: %v", len(errs), errs)
+	}
+
+	return nil
+}
+
+// derivePeerSecret deterministically derives a TCP-MD5 password from
+// key, the peer's ASN, its first neighbor IP, and generation, so the
+// same inputs always reproduce the same password and -rotate-secrets
+// is the only way to change it.
+func derivePeerSecret(key []byte, asn uint, neighbors []string, generation uint64) string {
+	mac := hmac.New(sha256.New, key)
+	fmt.Fprintf(mac, "AS%d", asn)
+	if len(neighbors) > 0 {
+		mac.Write([]byte(neighbors[0]))
+	}
+	var genBytes [8]byte
+	binary.BigEndian.PutUint64(genBytes[:], generation)
+	mac.Write(genBytes[:])
+
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))[:32]
+}
+
+func loadSecretKey(file string) ([]byte, error) {
+	if file == "" {
+		return nil, fmt.Errorf("no -secret-key configured")
+	}
+
+	key, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("read -secret-key %s: %v", file, err)
+	}
+
+	return key, nil
+}
+
+// secretFilePath and secretGenerationPath are keyed by both ASN and
+// peer name, matching how writePeerConfig (generate.go) disambiguates
+// same-ASN peers: two peers to the same network would otherwise share
+// one sidecar file and silently overwrite each other's secret.
+func secretFilePath(asn uint, peerName string) string {
+	return path.Join(*secretDir, "AS"+strconv.Itoa(int(asn))+"_"+normalize(peerName)+".pw")
+}
+
+func secretGenerationPath(asn uint, peerName string) string {
+	return path.Join(*secretDir, "AS"+strconv.Itoa(int(asn))+"_"+normalize(peerName)+".generation")
+}
+
+// secretGeneration returns the current generation counter for
+// peerName, defaulting to 0 if -rotate-secrets has never bumped it.
+func secretGeneration(asn uint, peerName string) (uint64, error) {
+	data, err := ioutil.ReadFile(secretGenerationPath(asn, peerName))
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("read generation counter: %v", err)
+	}
+
+	generation, err := strconv.ParseUint(strings.TrimSpace(string(data)), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("parse generation counter: %v", err)
+	}
+
+	return generation, nil
+}
+
+func writeSecretGeneration(asn uint, peerName string, generation uint64) error {
+	return ioutil.WriteFile(secretGenerationPath(asn, peerName), []byte(strconv.FormatUint(generation, 10)), 0600)
+}
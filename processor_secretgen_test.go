@@ -0,0 +1,86 @@
+package main
+
+import "testing"
+
+func TestDerivePeerSecretIsDeterministic(t *testing.T) {
+	key := []byte("test-hmac-key")
+
+	a := derivePeerSecret(key, 64500, []string{"203.0.113.1"}, 0)
+	b := derivePeerSecret(key, 64500, []string{"203.0.113.1"}, 0)
+	if a != b {
+		t.Errorf("derivePeerSecret is not deterministic: %q != %q", a, b)
+	}
+	if len(a) != 32 {
+		t.Errorf("len(secret) = %d, want 32", len(a))
+	}
+}
+
+func TestDerivePeerSecretChangesWithGeneration(t *testing.T) {
+	key := []byte("test-hmac-key")
+
+	gen0 := derivePeerSecret(key, 64500, []string{"203.0.113.1"}, 0)
+	gen1 := derivePeerSecret(key, 64500, []string{"203.0.113.1"}, 1)
+	if gen0 == gen1 {
+		t.Error("rotating the generation counter should change the derived secret")
+	}
+}
+
+func TestDerivePeerSecretChangesWithNeighbor(t *testing.T) {
+	key := []byte("test-hmac-key")
+
+	a := derivePeerSecret(key, 64500, []string{"203.0.113.1"}, 0)
+	b := derivePeerSecret(key, 64500, []string{"203.0.113.2"}, 0)
+	if a == b {
+		t.Error("different neighbor IPs should derive different secrets")
+	}
+}
+
+func TestSecretGenerationRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	old := *secretDir
+	*secretDir = dir
+	defer func() { *secretDir = old }()
+
+	gen, err := secretGeneration(64500, "example-peer")
+	if err != nil {
+		t.Fatalf("secretGeneration on a fresh dir: %v", err)
+	}
+	if gen != 0 {
+		t.Errorf("generation = %d, want 0 before any rotation", gen)
+	}
+
+	if err := writeSecretGeneration(64500, "example-peer", 3); err != nil {
+		t.Fatalf("writeSecretGeneration: %v", err)
+	}
+
+	gen, err = secretGeneration(64500, "example-peer")
+	if err != nil {
+		t.Fatalf("secretGeneration after writing: %v", err)
+	}
+	if gen != 3 {
+		t.Errorf("generation = %d, want 3", gen)
+	}
+}
+
+func TestSecretGenerationKeyedByPeerName(t *testing.T) {
+	dir := t.TempDir()
+	old := *secretDir
+	*secretDir = dir
+	defer func() { *secretDir = old }()
+
+	if err := writeSecretGeneration(64500, "peer-a", 1); err != nil {
+		t.Fatalf("writeSecretGeneration: %v", err)
+	}
+
+	gen, err := secretGeneration(64500, "peer-b")
+	if err != nil {
+		t.Fatalf("secretGeneration: %v", err)
+	}
+	if gen != 0 {
+		t.Errorf("generation for peer-b = %d, want 0 (same-ASN peers must not share state)", gen)
+	}
+
+	if secretFilePath(64500, "peer-a") == secretFilePath(64500, "peer-b") {
+		t.Error("secretFilePath must differ for two peers sharing an ASN")
+	}
+}
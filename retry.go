@@ -0,0 +1,58 @@
+package main
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// withRetry calls fn up to maxAttempts times, backing off
+// exponentially between attempts, but only when isTransient(err) says
+// the failure is worth retrying. The first non-transient error is
+// returned immediately without consuming further attempts.
+func withRetry(maxAttempts int, isTransient func(error) bool, fn func() error) error {
+	var err error
+	backoff := 500 * time.Millisecond
+
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		err = fn()
+		if err == nil {
+			return nil
+		}
+
+		if !isTransient(err) || attempt == maxAttempts {
+			return err
+		}
+
+		log.Warnf("Attempt %d/%d failed: %v, retrying in %s", attempt, maxAttempts, err, backoff)
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+
+	return err
+}
+
+// isTransientPeeringDBError reports whether err looks like a
+// transient PeeringDB failure (a network error or a 5xx response)
+// worth retrying, as opposed to a permanent one like a missing
+// PeeringDB entry (which retrying won't fix).
+func isTransientPeeringDBError(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+
+	var statusErr *peeringDBStatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode >= http.StatusInternalServerError
+	}
+
+	return false
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestWithRetrySucceedsAfterTransientErrors(t *testing.T) {
+	attempts := 0
+	err := withRetry(3, func(error) bool { return true }, func() error {
+		attempts++
+		if attempts < 3 {
+			return errors.New("transient")
+		}
+		return nil
+	})
+
+	if err != nil {
+		t.Fatalf("withRetry: %v", err)
+	}
+	if attempts != 3 {
+		t.Errorf("attempts = %d, want 3", attempts)
+	}
+}
+
+func TestWithRetryStopsImmediatelyOnNonTransientError(t *testing.T) {
+	attempts := 0
+	wantErr := errors.New("permanent")
+
+	err := withRetry(5, func(error) bool { return false }, func() error {
+		attempts++
+		return wantErr
+	})
+
+	if err != wantErr {
+		t.Errorf("err = %v, want %v", err, wantErr)
+	}
+	if attempts != 1 {
+		t.Errorf("attempts = %d, want 1 (non-transient errors must not be retried)", attempts)
+	}
+}
+
+func TestWithRetryGivesUpAfterMaxAttempts(t *testing.T) {
+	attempts := 0
+	wantErr := errors.New("always transient")
+
+	err := withRetry(3, func(error) bool { return true }, func() error {
+		attempts++
+		return wantErr
+	})
+
+	if err != wantErr {
+		t.Errorf("err = %v, want %v", err, wantErr)
+	}
+	if attempts != 3 {
+		t.Errorf("attempts = %d, want 3", attempts)
+	}
+}
+
+func TestIsTransientPeeringDBError(t *testing.T) {
+	cases := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"nil error", nil, false},
+		{"5xx status", &peeringDBStatusError{StatusCode: http.StatusBadGateway}, true},
+		{"4xx status", &peeringDBStatusError{StatusCode: http.StatusNotFound}, false},
+		{"other error", errors.New("parse failure"), false},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := isTransientPeeringDBError(c.err); got != c.want {
+				t.Errorf("isTransientPeeringDBError(%v) = %v, want %v", c.err, got, c.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,111 @@
+package main
+
+import "net"
+
+// VRP is a single Validated ROA Payload: an attestation that asn is
+// authorized to originate routes at or more specific than Prefix, up
+// to MaxLength bits.
+type VRP struct {
+	ASN       uint32
+	Prefix    *net.IPNet
+	MaxLength uint8
+}
+
+// rpkiVerdict is the outcome of RFC 6811 route origin validation for a
+// single prefix/ASN pair.
+type rpkiVerdict int
+
+const (
+	rpkiNotFound rpkiVerdict = iota
+	rpkiValid
+	rpkiInvalid
+)
+
+// trieNode is one bit of IP address space. vrps holds every VRP whose
+// own prefix terminates exactly at this node.
+type trieNode struct {
+	children [2]*trieNode
+	vrps     []VRP
+}
+
+// rpkiTrie is a binary longest-prefix-match trie over VRPs, kept
+// separate for IPv4 and IPv6 address space.
+type rpkiTrie struct {
+	root4 *trieNode
+	root6 *trieNode
+}
+
+// newRPKITrie builds an empty trie ready for inserts.
+func newRPKITrie() *rpkiTrie {
+	return &rpkiTrie{root4: &trieNode{}, root6: &trieNode{}}
+}
+
+// insert adds a VRP to the trie at the depth of its own prefix length.
+func (t *rpkiTrie) insert(v VRP) {
+	ip := v.Prefix.IP.To4()
+	root := t.root4
+	if ip == nil {
+		ip = v.Prefix.IP.To16()
+		root = t.root6
+	}
+
+	ones, _ := v.Prefix.Mask.Size()
+
+	node := root
+	for i := 0; i < ones; i++ {
+		bit := ipBit(ip, i)
+		if node.children[bit] == nil {
+			node.children[bit] = &trieNode{}
+		}
+		node = node.children[bit]
+	}
+	node.vrps = append(node.vrps, v)
+}
+
+// classify performs RFC 6811 route origin validation for prefix
+// announced by asn: Valid if a covering VRP authorizes asn up to this
+// prefix length, Invalid if a covering VRP exists but none authorize
+// asn (or the prefix is more specific than any covering MaxLength),
+// NotFound if no VRP covers the prefix at all.
+func (t *rpkiTrie) classify(prefix *net.IPNet, asn uint32) rpkiVerdict {
+	ip := prefix.IP.To4()
+	root := t.root4
+	if ip == nil {
+		ip = prefix.IP.To16()
+		root = t.root6
+	}
+
+	ones, _ := prefix.Mask.Size()
+
+	node := root
+	candidates := append([]VRP{}, node.vrps...)
+	for i := 0; i < ones; i++ {
+		bit := ipBit(ip, i)
+		if node.children[bit] == nil {
+			break
+		}
+		node = node.children[bit]
+		candidates = append(candidates, node.vrps...)
+	}
+
+	covered := false
+	for _, v := range candidates {
+		covered = true
+		if uint8(ones) > v.MaxLength {
+			continue
+		}
+		if v.ASN == asn {
+			return rpkiValid
+		}
+	}
+
+	if covered {
+		return rpkiInvalid
+	}
+	return rpkiNotFound
+}
+
+// ipBit returns the i'th bit (0-indexed, most significant first) of ip.
+func ipBit(ip []byte, i int) int {
+	return int(ip[i/8]>>(7-uint(i%8))) & 1
+}
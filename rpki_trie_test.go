@@ -0,0 +1,50 @@
+package main
+
+import (
+	"net"
+	"testing"
+)
+
+func TestRPKITrieClassify(t *testing.T) {
+	trie := newRPKITrie()
+
+	insert := func(asn uint32, cidr string, maxLength uint8) {
+		_, prefix, err := net.ParseCIDR(cidr)
+		if err != nil {
+			t.Fatalf("parse CIDR %q: %v", cidr, err)
+		}
+		trie.insert(VRP{ASN: asn, Prefix: prefix, MaxLength: maxLength})
+	}
+
+	insert(64500, "203.0.113.0/24", 24)
+	insert(64501, "198.51.100.0/23", 24)
+	insert(64502, "2001:db8::/32", 48)
+
+	cases := []struct {
+		name   string
+		prefix string
+		asn    uint32
+		want   rpkiVerdict
+	}{
+		{"exact match is valid", "203.0.113.0/24", 64500, rpkiValid},
+		{"covered but wrong origin is invalid", "203.0.113.0/24", 64501, rpkiInvalid},
+		{"more specific than MaxLength is invalid", "203.0.113.0/25", 64500, rpkiInvalid},
+		{"more specific within MaxLength is valid", "198.51.100.0/24", 64501, rpkiValid},
+		{"no covering VRP is not found", "192.0.2.0/24", 64500, rpkiNotFound},
+		{"ipv6 exact match is valid", "2001:db8::/32", 64502, rpkiValid},
+		{"ipv6 within MaxLength is valid", "2001:db8::/48", 64502, rpkiValid},
+		{"ipv6 more specific than MaxLength is invalid", "2001:db8::/49", 64502, rpkiInvalid},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			_, prefix, err := net.ParseCIDR(c.prefix)
+			if err != nil {
+				t.Fatalf("parse CIDR %q: %v", c.prefix, err)
+			}
+			if got := trie.classify(prefix, c.asn); got != c.want {
+				t.Errorf("classify(%s, AS%d) = %v, want %v", c.prefix, c.asn, got, c.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,202 @@
+package main
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// rtrReadTimeout bounds how long fetchVRPsFromRTR will wait on a
+// stalled cache server when ctx has no deadline of its own, so a
+// wedged RTR connection can't block reconcile() (and every future
+// -daemon tick or TriggerReconcile) forever.
+const rtrReadTimeout = 30 * time.Second
+
+// RTR PDU types we care about (RFC 8210 section 5). Serial
+// Notify/Query and router key PDUs aren't needed for a one-shot Reset
+// Query.
+const (
+	rtrPDUResetQuery    = 2
+	rtrPDUCacheResponse = 3
+	rtrPDUIPv4Prefix    = 4
+	rtrPDUIPv6Prefix    = 6
+	rtrPDUEndOfData     = 7
+	rtrPDUCacheReset    = 8
+	rtrPDUErrorReport   = 10
+)
+
+// loadVRPs resolves source (an "rtr://host:port" RFC 8210 cache server
+// or a path to an rpki-client/routinator JSON VRP dump) into a flat
+// list of VRPs.
+func loadVRPs(ctx context.Context, source string) ([]VRP, error) {
+	if addr := strings.TrimPrefix(source, "rtr://"); addr != source {
+		return fetchVRPsFromRTR(ctx, addr)
+	}
+	return loadVRPsFromFile(source)
+}
+
+// fetchVRPsFromRTR connects to an RTR cache server, issues a Reset
+// Query, and collects every VRP from the Prefix PDUs returned before
+// End of Data. The connection is bounded by ctx's deadline (falling
+// back to rtrReadTimeout when ctx has none) and closed the moment ctx
+// is cancelled, so a stalled server can't block the caller forever.
+func fetchVRPsFromRTR(ctx context.Context, addr string) ([]VRP, error) {
+	conn, err := net.DialTimeout("tcp", addr, 10*time.Second)
+	if err != nil {
+		return nil, fmt.Errorf("RTR dial %s: %v", addr, err)
+	}
+	defer conn.Close()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	} else {
+		conn.SetDeadline(time.Now().Add(rtrReadTimeout))
+	}
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.Close()
+		case <-done:
+		}
+	}()
+
+	// Reset Query: version 0, type 2, reserved (2 bytes), length 8
+	if _, err := conn.Write([]byte{0, rtrPDUResetQuery, 0, 0, 0, 0, 0, 8}); err != nil {
+		return nil, fmt.Errorf("RTR write reset query: %v", err)
+	}
+
+	var vrps []VRP
+
+	for {
+		header := make([]byte, 8)
+		if _, err := io.ReadFull(conn, header); err != nil {
+			return nil, fmt.Errorf("RTR read header: %v", err)
+		}
+
+		pduType := header[1]
+		length := binary.BigEndian.Uint32(header[4:8])
+		if length < 8 {
+			return nil, fmt.Errorf("RTR PDU with invalid length %d", length)
+		}
+
+		body := make([]byte, length-8)
+		if len(body) > 0 {
+			if _, err := io.ReadFull(conn, body); err != nil {
+				return nil, fmt.Errorf("RTR read body: %v", err)
+			}
+		}
+
+		switch pduType {
+		case rtrPDUCacheResponse, rtrPDUCacheReset:
+			// No payload to act on; prefix PDUs follow a Cache Response
+		case rtrPDUIPv4Prefix:
+			vrp, err := decodeIPv4PrefixPDU(body)
+			if err != nil {
+				return nil, err
+			}
+			vrps = append(vrps, vrp)
+		case rtrPDUIPv6Prefix:
+			vrp, err := decodeIPv6PrefixPDU(body)
+			if err != nil {
+				return nil, err
+			}
+			vrps = append(vrps, vrp)
+		case rtrPDUEndOfData:
+			return vrps, nil
+		case rtrPDUErrorReport:
+			return nil, fmt.Errorf("RTR server returned an error report")
+		default:
+			return nil, fmt.Errorf("RTR unexpected PDU type %d", pduType)
+		}
+	}
+}
+
+// decodeIPv4PrefixPDU parses the body of an IPv4 Prefix PDU (RFC 8210
+// section 5.6): flags(1), prefix length(1), max length(1), zero(1),
+// prefix(4), asn(4).
+func decodeIPv4PrefixPDU(body []byte) (VRP, error) {
+	if len(body) != 12 {
+		return VRP{}, fmt.Errorf("RTR malformed IPv4 prefix PDU (length %d)", len(body))
+	}
+
+	prefixLen := body[1]
+	maxLen := body[2]
+	mask := net.CIDRMask(int(prefixLen), 32)
+	ip := net.IPv4(body[4], body[5], body[6], body[7]).To4().Mask(mask)
+	asn := binary.BigEndian.Uint32(body[8:12])
+
+	return VRP{ASN: asn, Prefix: &net.IPNet{IP: ip, Mask: mask}, MaxLength: maxLen}, nil
+}
+
+// decodeIPv6PrefixPDU parses the body of an IPv6 Prefix PDU (RFC 8210
+// section 5.7): flags(1), prefix length(1), max length(1), zero(1),
+// prefix(16), asn(4).
+func decodeIPv6PrefixPDU(body []byte) (VRP, error) {
+	if len(body) != 24 {
+		return VRP{}, fmt.Errorf("RTR malformed IPv6 prefix PDU (length %d)", len(body))
+	}
+
+	prefixLen := body[1]
+	maxLen := body[2]
+	mask := net.CIDRMask(int(prefixLen), 128)
+	ip := net.IP(append([]byte{}, body[4:20]...)).Mask(mask)
+	asn := binary.BigEndian.Uint32(body[20:24])
+
+	return VRP{ASN: asn, Prefix: &net.IPNet{IP: ip, Mask: mask}, MaxLength: maxLen}, nil
+}
+
+// vrpDump is the shape rpki-client and routinator's `-o json` output
+// use: a top-level object with a "roas" array of
+// {asn, prefix, maxLength, ta} entries.
+type vrpDump struct {
+	ROAs []struct {
+		ASN       string `json:"asn"`
+		Prefix    string `json:"prefix"`
+		MaxLength uint8  `json:"maxLength"`
+		TA        string `json:"ta"`
+	} `json:"roas"`
+}
+
+// loadVRPsFromFile reads a rpki-client/routinator JSON VRP dump.
+func loadVRPsFromFile(path string) ([]VRP, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read VRP file %s: %v", path, err)
+	}
+
+	var dump vrpDump
+	if err := json.Unmarshal(data, &dump); err != nil {
+		return nil, fmt.Errorf("parse VRP file %s: %v", path, err)
+	}
+
+	vrps := make([]VRP, 0, len(dump.ROAs))
+	for _, entry := range dump.ROAs {
+		_, prefix, err := net.ParseCIDR(entry.Prefix)
+		if err != nil {
+			log.Warnf("Skipping VRP with invalid prefix %q: %v", entry.Prefix, err)
+			continue
+		}
+
+		asn, err := strconv.ParseUint(strings.TrimPrefix(entry.ASN, "AS"), 10, 32)
+		if err != nil {
+			log.Warnf("Skipping VRP with invalid ASN %q: %v", entry.ASN, err)
+			continue
+		}
+
+		vrps = append(vrps, VRP{ASN: uint32(asn), Prefix: prefix, MaxLength: entry.MaxLength})
+	}
+
+	return vrps, nil
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"encoding/binary"
+	"net"
+	"testing"
+)
+
+func TestDecodeIPv4PrefixPDU(t *testing.T) {
+	body := make([]byte, 12)
+	body[1] = 24 // prefix length
+	body[2] = 32 // max length
+	copy(body[4:8], net.IPv4(203, 0, 113, 0).To4())
+	binary.BigEndian.PutUint32(body[8:12], 64500)
+
+	vrp, err := decodeIPv4PrefixPDU(body)
+	if err != nil {
+		t.Fatalf("decodeIPv4PrefixPDU: %v", err)
+	}
+	if vrp.ASN != 64500 {
+		t.Errorf("ASN = %d, want 64500", vrp.ASN)
+	}
+	if vrp.MaxLength != 32 {
+		t.Errorf("MaxLength = %d, want 32", vrp.MaxLength)
+	}
+	if got := vrp.Prefix.String(); got != "203.0.113.0/24" {
+		t.Errorf("Prefix = %s, want 203.0.113.0/24", got)
+	}
+}
+
+func TestDecodeIPv4PrefixPDUMalformed(t *testing.T) {
+	if _, err := decodeIPv4PrefixPDU(make([]byte, 11)); err == nil {
+		t.Error("expected an error for a truncated body, got nil")
+	}
+}
+
+func TestDecodeIPv6PrefixPDU(t *testing.T) {
+	body := make([]byte, 24)
+	body[1] = 48 // prefix length
+	body[2] = 64 // max length
+	copy(body[4:20], net.ParseIP("2001:db8::").To16())
+	binary.BigEndian.PutUint32(body[20:24], 64501)
+
+	vrp, err := decodeIPv6PrefixPDU(body)
+	if err != nil {
+		t.Fatalf("decodeIPv6PrefixPDU: %v", err)
+	}
+	if vrp.ASN != 64501 {
+		t.Errorf("ASN = %d, want 64501", vrp.ASN)
+	}
+	if vrp.MaxLength != 64 {
+		t.Errorf("MaxLength = %d, want 64", vrp.MaxLength)
+	}
+	if got := vrp.Prefix.String(); got != "2001:db8::/48" {
+		t.Errorf("Prefix = %s, want 2001:db8::/48", got)
+	}
+}
+
+func TestDecodeIPv6PrefixPDUMalformed(t *testing.T) {
+	if _, err := decodeIPv6PrefixPDU(make([]byte, 23)); err == nil {
+		t.Error("expected an error for a truncated body, got nil")
+	}
+}
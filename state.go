@@ -0,0 +1,22 @@
+package main
+
+import "sync"
+
+// State is the mutable data shared between pipeline Processors during a
+// single run. Processors read and enrich Config.Peers in place; State
+// only adds the bookkeeping (error aggregation) needed to let a bad
+// processor skip itself instead of aborting the whole run.
+type State struct {
+	Config Config
+
+	mu     sync.Mutex
+	Errors []error
+}
+
+// AddError appends a processor error to the shared state. It is safe to
+// call from multiple goroutines.
+func (s *State) AddError(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Errors = append(s.Errors, err)
+}